@@ -1,35 +1,131 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	_ "time/tzdata" // the alpine runtime image ships without a zoneinfo database
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 
+	"booking-service/internal/auth"
+	"booking-service/internal/events"
 	"booking-service/internal/handlers"
+	"booking-service/internal/meeting"
 	"booking-service/internal/middleware"
 	"booking-service/internal/services"
+	"booking-service/internal/store"
+	"booking-service/internal/store/memory"
+	"booking-service/internal/store/postgres"
 )
 
+// shutdownTimeout is how long in-flight requests get to finish once the service is told to stop
+const shutdownTimeout = 20 * time.Second
+
 func main() {
 	godotenv.Load()
 
+	// SIGINT or SIGTERM stops the background workers and drains the HTTP server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var workers sync.WaitGroup
+	background := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "3007"
 	}
 
+	// Refuse to start without a key rather than accept tokens signed with a guessable one
+	authConfig, err := auth.ConfigFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid JWT configuration: %v", err)
+	}
+	verifier, err := auth.NewVerifier(ctx, authConfig)
+	if err != nil {
+		log.Fatalf("Invalid JWT configuration: %v", err)
+	}
+	background(verifier.RunKeyRefresh)
+
+	// Without a database everything runs against the in-memory store, e.g. for local development
+	var st store.Store
+	if dbURL := os.Getenv("DATABASE_URL"); dbURL != "" {
+		pg, err := postgres.New(ctx, dbURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to database: %v", err)
+		}
+		st = pg
+	} else {
+		log.Printf("DATABASE_URL not set, using the in-memory store")
+		st = memory.New()
+	}
+	defer st.Close()
+
 	// Initialize services
-	bookingService := services.NewBookingService()
-	availabilityService := services.NewAvailabilityService()
+	bookingService := services.NewBookingService(st)
+	availabilityService := services.NewAvailabilityService(st)
+
+	bookingService.Meetings = meetingProvider()
+
+	// Relay outbox events: reminders to the notification service, everything else to RabbitMQ.
+	// Events nothing is configured to deliver stay queued in the outbox.
+	if publisher := eventPublisher(); publisher != nil {
+		defer publisher.Close()
+		relay := events.NewOutboxRelay(st, publisher)
+		relay.EventTypes = publisher.Routes()
+		background(relay.Run)
+	}
+
+	// Holds stop blocking slots the moment they expire; the sweeper just clears them out
+	durationEnv("BOOKING_HOLD_TTL", &bookingService.HoldTTL)
+	background(func(ctx context.Context) { bookingService.RunHoldSweeper(ctx, time.Minute) })
+
+	// Expire unanswered bookings and move sessions along as their start and end times pass
+	durationEnv("BOOKING_PENDING_TTL", &bookingService.PendingTTL)
+	durationEnv("BOOKING_COMPLETION_GRACE", &bookingService.CompletionGrace)
+	lifecycleInterval := time.Minute
+	durationEnv("BOOKING_LIFECYCLE_INTERVAL", &lifecycleInterval)
+	background(func(ctx context.Context) { bookingService.RunLifecycleScheduler(ctx, lifecycleInterval) })
+
+	// Reminders are stored with their bookings; the dispatcher sends them as they come due
+	reminderInterval := time.Minute
+	durationEnv("BOOKING_REMINDER_INTERVAL", &reminderInterval)
+	background(func(ctx context.Context) { bookingService.RunReminderDispatcher(ctx, reminderInterval) })
+
+	idempotency := middleware.NewIdempotency(st.Idempotency())
+	durationEnv("IDEMPOTENCY_KEY_TTL", &idempotency.TTL)
+	background(func(ctx context.Context) { idempotency.RunSweeper(ctx, time.Hour) })
 
 	// Initialize handlers
 	bookingHandler := handlers.NewBookingHandler(bookingService)
+	bookingHandler.PublicBaseURL = publicBaseURL()
 	availabilityHandler := handlers.NewAvailabilityHandler(availabilityService)
+	adminHandler := handlers.NewAdminHandler(bookingService, availabilityService)
 
 	// Setup router
 	r := gin.Default()
+	r.Use(middleware.Errors())
+	r.Use(middleware.UUIDParams("id", "holdId", "seriesId", "requestId", "workerId", "slotId"))
+	// Only the proxies in TRUSTED_PROXIES may report the client's address in X-Forwarded-For
+	if err := r.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Health check
 	r.GET("/health", func(c *gin.Context) {
@@ -40,28 +136,199 @@ func main() {
 	api := r.Group("/api")
 	{
 		bookings := api.Group("/bookings")
-		bookings.Use(middleware.AuthMiddleware())
+		bookings.Use(middleware.AuthMiddleware(verifier))
 		{
-			bookings.POST("", bookingHandler.CreateBooking)
+			bookings.POST("", idempotency.Handler(), bookingHandler.CreateBooking)
 			bookings.GET("", bookingHandler.GetUserBookings)
+			bookings.GET("/quote", bookingHandler.QuoteBooking)
+			bookings.GET("/stats", bookingHandler.GetBookingStats)
+			bookings.POST("/holds", bookingHandler.CreateHold)
+			bookings.DELETE("/holds/:holdId", bookingHandler.ReleaseHold)
+			bookings.POST("/calendar-feed", bookingHandler.CreateCalendarFeed)
+			bookings.DELETE("/calendar-feed", bookingHandler.RevokeCalendarFeed)
+			bookings.GET("/reminder-preferences", bookingHandler.GetReminderPreferences)
+			bookings.PUT("/reminder-preferences", bookingHandler.UpdateReminderPreferences)
+			bookings.DELETE("/reminder-preferences", bookingHandler.ResetReminderPreferences)
+			bookings.POST("/series", bookingHandler.CreateBookingSeries)
+			bookings.GET("/series/:seriesId", bookingHandler.GetBookingSeries)
+			bookings.PUT("/series/:seriesId", bookingHandler.UpdateBookingSeries)
+			bookings.POST("/series/:seriesId/cancel", bookingHandler.CancelBookingSeries)
 			bookings.GET("/:id", bookingHandler.GetBooking)
 			bookings.PUT("/:id", bookingHandler.UpdateBooking)
-			bookings.POST("/:id/confirm", bookingHandler.ConfirmBooking)
-			bookings.POST("/:id/cancel", bookingHandler.CancelBooking)
-			bookings.POST("/:id/complete", bookingHandler.CompleteBooking)
+			bookings.GET("/:id/history", bookingHandler.GetBookingHistory)
+			bookings.GET("/:id/reminders", bookingHandler.GetBookingReminders)
+			bookings.POST("/:id/confirm", idempotency.Handler(), bookingHandler.ConfirmBooking)
+			bookings.POST("/:id/decline", bookingHandler.DeclineBooking)
+			bookings.POST("/:id/start", bookingHandler.StartBooking)
+			bookings.POST("/:id/cancel", idempotency.Handler(), bookingHandler.CancelBooking)
+			bookings.GET("/:id/cancel/preview", bookingHandler.PreviewCancellation)
+			bookings.POST("/:id/complete", idempotency.Handler(), bookingHandler.CompleteBooking)
+			bookings.POST("/:id/no-show", bookingHandler.MarkNoShow)
+			bookings.POST("/:id/reschedule", bookingHandler.ProposeReschedule)
+			bookings.GET("/:id/reschedule", bookingHandler.GetRescheduleRequests)
+			bookings.POST("/:id/reschedule/:requestId/accept", bookingHandler.AcceptReschedule)
+			bookings.POST("/:id/reschedule/:requestId/reject", bookingHandler.RejectReschedule)
+		}
+
+		api.GET("/calendar/:token", bookingHandler.GetCalendarFeed)
+
+		// Support staff, identified by the role claim of their token, can see and fix any booking
+		admin := api.Group("/admin/bookings")
+		admin.Use(middleware.AuthMiddleware(verifier), middleware.RequireRole("admin", "support"))
+		{
+			admin.GET("", adminHandler.SearchBookings)
+			admin.GET("/workers/:workerId/availability", adminHandler.GetWorkerCalendar)
+			admin.GET("/:id", adminHandler.GetBooking)
+			admin.GET("/:id/history", adminHandler.GetBookingHistory)
+			admin.POST("/:id/cancel", adminHandler.CancelBooking)
+			admin.POST("/:id/status", adminHandler.OverrideStatus)
+		}
+
+		// Booking events the outbox relay gave up publishing
+		outbox := api.Group("/admin/outbox")
+		outbox.Use(middleware.AuthMiddleware(verifier), middleware.RequireRole("admin", "support"))
+		{
+			outbox.GET("/dead-letters", adminHandler.ListDeadLetters)
+			outbox.POST("/dead-letters/:id/requeue", adminHandler.RequeueDeadLetter)
 		}
 
 		availability := api.Group("/availability")
 		{
 			availability.GET("/worker/:workerId", availabilityHandler.GetWorkerAvailability)
-			availability.GET("/worker/:workerId/slots", availabilityHandler.GetAvailableSlots)
-			availability.Use(middleware.AuthMiddleware())
+			// Signed-in clients see the slots they hold themselves as available
+			availability.GET("/worker/:workerId/slots", middleware.OptionalAuthMiddleware(verifier), availabilityHandler.GetAvailableSlots)
+			availability.GET("/worker/:workerId/slots/range", middleware.OptionalAuthMiddleware(verifier), availabilityHandler.GetAvailableSlotsRange)
+			availability.GET("/worker/:workerId/rules", availabilityHandler.GetSchedulingRules)
+			availability.GET("/worker/:workerId/cancellation-policy", availabilityHandler.GetCancellationPolicy)
+			availability.GET("/worker/:workerId/rate-card", availabilityHandler.GetRateCard)
+			availability.Use(middleware.AuthMiddleware(verifier))
 			availability.PUT("/worker/:workerId", availabilityHandler.UpdateAvailability)
+			availability.PUT("/worker/:workerId/rules", availabilityHandler.UpdateSchedulingRules)
+			availability.PUT("/worker/:workerId/cancellation-policy", availabilityHandler.UpdateCancellationPolicy)
+			availability.PUT("/worker/:workerId/rate-card", availabilityHandler.UpdateRateCard)
 			availability.POST("/worker/:workerId/block", availabilityHandler.BlockTimeSlot)
 			availability.DELETE("/worker/:workerId/block/:slotId", availabilityHandler.UnblockTimeSlot)
 		}
 	}
 
-	log.Printf("Booking service running on port %s", port)
-	r.Run(":" + port)
+	srv := &http.Server{Addr: ":" + port, Handler: r, ReadHeaderTimeout: 10 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Booking service running on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serveErr <- err
+		}
+		close(serveErr)
+	}()
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			log.Printf("HTTP server failed: %v", err)
+		}
+		stop()
+	case <-ctx.Done():
+		log.Printf("Shutting down")
+	}
+
+	// Finish in-flight requests before the workers and the store they share go away
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	workers.Wait()
+}
+
+// eventPublisher routes booking events to RabbitMQ when RABBITMQ_URL is set, and reminders to the
+// notification service when NOTIFICATION_SERVICE_URL and INTERNAL_SERVICE_KEY are. It is nil when
+// neither is configured.
+func eventPublisher() *events.RoutingPublisher {
+	routes := map[string]events.Publisher{}
+
+	if rabbitURL := os.Getenv("RABBITMQ_URL"); rabbitURL != "" {
+		exchange := os.Getenv("BOOKING_EVENTS_EXCHANGE")
+		if exchange == "" {
+			exchange = "bookings"
+		}
+		amqp := events.NewAMQPPublisher(rabbitURL, exchange)
+		for _, eventType := range events.Types {
+			if eventType != events.BookingReminder {
+				routes[eventType] = amqp
+			}
+		}
+	} else {
+		log.Printf("RABBITMQ_URL not set, booking events will not be published")
+	}
+
+	notificationURL, internalKey := os.Getenv("NOTIFICATION_SERVICE_URL"), os.Getenv("INTERNAL_SERVICE_KEY")
+	if notificationURL != "" && internalKey != "" {
+		routes[events.BookingReminder] = events.NewNotificationPublisher(notificationURL, internalKey)
+	} else {
+		log.Printf("NOTIFICATION_SERVICE_URL or INTERNAL_SERVICE_KEY not set, booking reminders will not be delivered")
+	}
+
+	if len(routes) == 0 {
+		return nil
+	}
+	return events.NewRoutingPublisher(routes)
+}
+
+// meetingProvider picks the provider remote bookings get their rooms from, Jitsi unless
+// MEETING_PROVIDER says otherwise
+func meetingProvider() services.MeetingProvider {
+	switch name := os.Getenv("MEETING_PROVIDER"); name {
+	case "", "jitsi":
+		baseURL := os.Getenv("MEETING_BASE_URL")
+		if baseURL == "" {
+			baseURL = meeting.DefaultJitsiURL
+		}
+		return meeting.NewJitsiProvider(baseURL, os.Getenv("MEETING_ROOM_PREFIX"))
+	case "fake":
+		return meeting.NewFakeProvider()
+	case "none":
+		log.Printf("MEETING_PROVIDER is none, remote bookings will not get meeting links")
+		return nil
+	default:
+		log.Fatalf("Unknown MEETING_PROVIDER %q", name)
+		return nil
+	}
+}
+
+// publicBaseURL is the origin in PUBLIC_BASE_URL that links handed to clients are built on
+func publicBaseURL() string {
+	value := os.Getenv("PUBLIC_BASE_URL")
+	if value == "" {
+		log.Printf("PUBLIC_BASE_URL not set, calendar feed URLs will use the address requests reach this server on")
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Fatalf("Invalid PUBLIC_BASE_URL %q", value)
+	}
+	return value
+}
+
+// trustedProxies are the comma-separated addresses or CIDRs in TRUSTED_PROXIES; none by default
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// durationEnv overrides dst with the positive duration in the environment variable, if set
+func durationEnv(name string, dst *time.Duration) {
+	value := os.Getenv(name)
+	if value == "" {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("Invalid %s %q", name, value)
+	}
+	*dst = d
 }
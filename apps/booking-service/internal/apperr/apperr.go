@@ -0,0 +1,93 @@
+// Package apperr holds the errors services return to describe what went wrong in domain terms.
+// Each error has a Kind, which decides the HTTP status, and a stable Code clients can match on.
+// Any other error is an internal failure, and its text is never shown to clients.
+package apperr
+
+import "net/http"
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	// KindBadRequest is a malformed request, such as an unparseable query parameter
+	KindBadRequest
+	// KindUnauthorized is a request without valid credentials
+	KindUnauthorized
+	KindNotFound
+	KindForbidden
+	KindInvalidTransition
+	KindConflict
+	// KindValidation is a well-formed request the domain rules reject
+	KindValidation
+	KindGone
+	KindPreconditionFailed
+	// KindUnavailable is a dependency that failed; the request may succeed if retried
+	KindUnavailable
+)
+
+var statuses = map[Kind]int{
+	KindInternal:           http.StatusInternalServerError,
+	KindBadRequest:         http.StatusBadRequest,
+	KindUnauthorized:       http.StatusUnauthorized,
+	KindNotFound:           http.StatusNotFound,
+	KindForbidden:          http.StatusForbidden,
+	KindInvalidTransition:  http.StatusConflict,
+	KindConflict:           http.StatusConflict,
+	KindValidation:         http.StatusUnprocessableEntity,
+	KindGone:               http.StatusGone,
+	KindPreconditionFailed: http.StatusPreconditionFailed,
+	KindUnavailable:        http.StatusServiceUnavailable,
+}
+
+// Status is the HTTP status errors of kind k are answered with
+func (k Kind) Status() int {
+	if status, ok := statuses[k]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is a domain error. Sentinels are declared once and compared with errors.Is; wrap them
+// with fmt.Errorf("%w: ...") to add detail to the message.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	// Details is sent to the client alongside the message, e.g. the conflicting slots
+	Details any
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// WithDetails returns a copy of e carrying details
+func (e *Error) WithDetails(details any) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func BadRequest(code, message string) *Error   { return New(KindBadRequest, code, message) }
+func Unauthorized(code, message string) *Error { return New(KindUnauthorized, code, message) }
+func NotFound(code, message string) *Error     { return New(KindNotFound, code, message) }
+func Forbidden(code, message string) *Error    { return New(KindForbidden, code, message) }
+func Conflict(code, message string) *Error     { return New(KindConflict, code, message) }
+func Validation(code, message string) *Error   { return New(KindValidation, code, message) }
+func Gone(code, message string) *Error         { return New(KindGone, code, message) }
+
+func InvalidTransition(code, message string) *Error {
+	return New(KindInvalidTransition, code, message)
+}
+
+func PreconditionFailed(code, message string) *Error {
+	return New(KindPreconditionFailed, code, message)
+}
+
+func Unavailable(code, message string) *Error {
+	return New(KindUnavailable, code, message)
+}
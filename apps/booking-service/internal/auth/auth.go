@@ -0,0 +1,179 @@
+// Package auth verifies the access tokens issued by the auth service.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	DefaultClockSkew  = 30 * time.Second
+	DefaultKeyRefresh = 5 * time.Minute
+)
+
+var (
+	ErrNoKeys       = errors.New("no JWT verification key configured: set JWT_SECRET, JWT_JWKS_FILE or JWT_JWKS_URL")
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Claims are the claims of an access token
+type Claims struct {
+	UserID string `json:"userId"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Config decides which tokens are accepted
+type Config struct {
+	// Secret is the HMAC key for tokens without a kid
+	Secret string
+	// KeySource is a JWKS file path or http(s) URL, reloaded every KeyRefresh
+	KeySource  string
+	KeyRefresh time.Duration
+	// Algorithms accepted; defaults to HS256 with a Secret plus the algorithms the JWKS keys pin
+	Algorithms []string
+	// Issuer and Audience, when set, must match the iss and aud claims
+	Issuer    string
+	Audience  string
+	ClockSkew time.Duration
+}
+
+// ConfigFromEnv reads the JWT_* environment variables
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Secret:     os.Getenv("JWT_SECRET"),
+		KeySource:  os.Getenv("JWT_JWKS_URL"),
+		KeyRefresh: DefaultKeyRefresh,
+		Issuer:     os.Getenv("JWT_ISSUER"),
+		Audience:   os.Getenv("JWT_AUDIENCE"),
+		ClockSkew:  DefaultClockSkew,
+	}
+	if file := os.Getenv("JWT_JWKS_FILE"); file != "" {
+		if cfg.KeySource != "" {
+			return cfg, errors.New("set only one of JWT_JWKS_FILE and JWT_JWKS_URL")
+		}
+		cfg.KeySource = file
+	}
+
+	if algs := os.Getenv("JWT_ALGORITHMS"); algs != "" {
+		for _, alg := range strings.Split(algs, ",") {
+			if alg = strings.TrimSpace(alg); alg != "" {
+				cfg.Algorithms = append(cfg.Algorithms, alg)
+			}
+		}
+	}
+
+	if skew := os.Getenv("JWT_CLOCK_SKEW"); skew != "" {
+		d, err := time.ParseDuration(skew)
+		if err != nil || d < 0 {
+			return cfg, fmt.Errorf("invalid JWT_CLOCK_SKEW %q", skew)
+		}
+		cfg.ClockSkew = d
+	}
+	if refresh := os.Getenv("JWT_JWKS_REFRESH"); refresh != "" {
+		d, err := time.ParseDuration(refresh)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid JWT_JWKS_REFRESH %q", refresh)
+		}
+		cfg.KeyRefresh = d
+	}
+	return cfg, nil
+}
+
+// Verifier checks access tokens against a Config
+type Verifier struct {
+	keys    *Keyset
+	refresh time.Duration
+	parser  *jwt.Parser
+}
+
+// NewVerifier loads the keys and fails if there are none, so the service never starts
+// accepting tokens it cannot verify
+func NewVerifier(ctx context.Context, cfg Config) (*Verifier, error) {
+	var static []Key
+	if cfg.Secret != "" {
+		static = append(static, Key{Material: []byte(cfg.Secret)})
+	}
+	keys := NewKeyset(static, cfg.KeySource)
+	if err := keys.Reload(ctx); err != nil {
+		return nil, fmt.Errorf("loading JWT keys: %w", err)
+	}
+	if keys.Len() == 0 {
+		return nil, ErrNoKeys
+	}
+
+	algs := cfg.Algorithms
+	if len(algs) == 0 {
+		if cfg.Secret != "" {
+			algs = append(algs, jwt.SigningMethodHS256.Alg())
+		}
+		algs = append(algs, keys.Algorithms()...)
+	}
+	if len(algs) == 0 {
+		return nil, errors.New("the JWKS keys pin no algorithm: set JWT_ALGORITHMS")
+	}
+	for _, alg := range algs {
+		if alg == "none" || jwt.GetSigningMethod(alg) == nil {
+			return nil, fmt.Errorf("unsupported JWT algorithm %q", alg)
+		}
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(algs),
+		jwt.WithLeeway(cfg.ClockSkew),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	}
+	if cfg.Issuer != "" {
+		options = append(options, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		options = append(options, jwt.WithAudience(cfg.Audience))
+	}
+
+	return &Verifier{keys: keys, refresh: cfg.KeyRefresh, parser: jwt.NewParser(options...)}, nil
+}
+
+// Verify returns the claims of a valid token
+func (v *Verifier) Verify(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := v.parser.ParseWithClaims(tokenString, claims, v.key)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidToken
+	}
+	if claims.UserID == "" {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+// key picks the key named by the token's kid header
+func (v *Verifier) key(token *jwt.Token) (interface{}, error) {
+	kid := ""
+	if raw, ok := token.Header["kid"]; ok {
+		if kid, ok = raw.(string); !ok {
+			return nil, errors.New("kid is not a string")
+		}
+	}
+
+	key, ok := v.keys.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+	if key.Algorithm != "" && key.Algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("kid %q does not accept %s", kid, token.Method.Alg())
+	}
+	return key.Material, nil
+}
+
+// RunKeyRefresh reloads the JWKS keys until ctx is cancelled, picking up rotated keys
+func (v *Verifier) RunKeyRefresh(ctx context.Context) {
+	v.keys.Run(ctx, v.refresh)
+}
@@ -0,0 +1,263 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func rsaJWK(kid, alg string, pub *rsa.PublicKey) jwk {
+	return jwk{Kty: "RSA", Kid: kid, Alg: alg, Use: "sig", N: b64(pub.N.Bytes()), E: b64(big.NewInt(int64(pub.E)).Bytes())}
+}
+
+func ecJWK(kid string, pub *ecdsa.PublicKey) jwk {
+	return jwk{Kty: "EC", Kid: kid, Alg: "ES256", Crv: "P-256", X: b64(pub.X.FillBytes(make([]byte, 32))), Y: b64(pub.Y.FillBytes(make([]byte, 32)))}
+}
+
+// writeJWKS writes a JWKS document of keys to a file and returns its path
+func writeJWKS(t *testing.T, path string, keys ...jwk) string {
+	t.Helper()
+	data, err := json.Marshal(map[string][]jwk{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwks := writeJWKS(t, filepath.Join(t.TempDir(), "jwks.json"), rsaJWK("rsa-1", "RS256", &rsaKey.PublicKey), ecJWK("ec-1", &ecKey.PublicKey))
+
+	v, err := NewVerifier(context.Background(), Config{
+		Secret: testSecret, KeySource: jwks, Issuer: "auth-service", Audience: "booking-service", ClockSkew: DefaultClockSkew,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	claims := func(edit func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"userId": "user-1", "role": "client",
+			"iss": "auth-service", "aud": "booking-service",
+			"iat": now.Unix(), "exp": now.Add(time.Hour).Unix(),
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{name: "HMAC without kid", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), claims(nil)), valid: true},
+		{name: "RSA key from the JWKS", token: sign(t, jwt.SigningMethodRS256, "rsa-1", rsaKey, claims(nil)), valid: true},
+		{name: "EC key from the JWKS", token: sign(t, jwt.SigningMethodES256, "ec-1", ecKey, claims(nil)), valid: true},
+		{name: "expired within the clock skew", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c jwt.MapClaims) { c["exp"] = now.Add(-10 * time.Second).Unix() })), valid: true},
+		{name: "expired", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Hour).Unix() }))},
+		{name: "no expiry", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c jwt.MapClaims) { delete(c, "exp") }))},
+		{name: "issued in the future", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c jwt.MapClaims) { c["iat"] = now.Add(time.Hour).Unix() }))},
+		{name: "wrong issuer", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c jwt.MapClaims) { c["iss"] = "someone-else" }))},
+		{name: "wrong audience", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c jwt.MapClaims) { c["aud"] = "other-service" }))},
+		{name: "no user", token: sign(t, jwt.SigningMethodHS256, "", []byte(testSecret), claims(func(c jwt.MapClaims) { delete(c, "userId") }))},
+		{name: "wrong secret", token: sign(t, jwt.SigningMethodHS256, "", []byte("guess"), claims(nil))},
+		{name: "unknown kid", token: sign(t, jwt.SigningMethodRS256, "rsa-2", rsaKey, claims(nil))},
+		{name: "kid pinned to another algorithm", token: sign(t, jwt.SigningMethodES256, "rsa-1", ecKey, claims(nil))},
+		{name: "unsigned", token: sign(t, jwt.SigningMethodNone, "", jwt.UnsafeAllowNoneSignatureType, claims(nil))},
+		{name: "garbage", token: "not.a.token"},
+	}
+	for _, tt := range tests {
+		got, err := v.Verify(tt.token)
+		if tt.valid {
+			if err != nil || got.UserID != "user-1" {
+				t.Errorf("%s: Verify = %v, %v, want user-1", tt.name, got, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, ErrInvalidToken)
+		}
+	}
+}
+
+func TestNewVerifier(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	pinned := writeJWKS(t, filepath.Join(dir, "pinned.json"), rsaJWK("rsa-1", "RS256", &rsaKey.PublicKey))
+	unpinned := writeJWKS(t, filepath.Join(dir, "unpinned.json"), rsaJWK("rsa-1", "", &rsaKey.PublicKey))
+
+	tests := []struct {
+		name string
+		cfg  Config
+		ok   bool
+	}{
+		{name: "secret", cfg: Config{Secret: testSecret}, ok: true},
+		{name: "pinned JWKS", cfg: Config{KeySource: pinned}, ok: true},
+		{name: "unpinned JWKS with algorithms", cfg: Config{KeySource: unpinned, Algorithms: []string{"RS256"}}, ok: true},
+		{name: "no keys", cfg: Config{}},
+		{name: "unpinned JWKS without algorithms", cfg: Config{KeySource: unpinned}},
+		{name: "missing JWKS file", cfg: Config{KeySource: filepath.Join(dir, "missing.json")}},
+		{name: "none algorithm", cfg: Config{Secret: testSecret, Algorithms: []string{"none"}}},
+		{name: "unknown algorithm", cfg: Config{Secret: testSecret, Algorithms: []string{"XX999"}}},
+	}
+	for _, tt := range tests {
+		_, err := NewVerifier(context.Background(), tt.cfg)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: NewVerifier error = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestParseJWKS(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offCurve := ecJWK("ec-bad", &ecKey.PublicKey)
+	offCurve.Y = b64(big.NewInt(1).FillBytes(make([]byte, 32)))
+
+	tests := []struct {
+		name string
+		keys []jwk
+		want []string
+		err  bool
+	}{
+		{name: "every key type", keys: []jwk{
+			{Kty: "oct", Kid: "hmac", Alg: "HS256", K: b64([]byte(testSecret))},
+			rsaJWK("rsa", "RS256", &rsaKey.PublicKey),
+			ecJWK("ec", &ecKey.PublicKey),
+			{Kty: "OKP", Kid: "ed", Alg: "EdDSA", Crv: "Ed25519", X: b64(edKey)},
+		}, want: []string{"hmac", "rsa", "ec", "ed"}},
+		{name: "encryption keys are skipped", keys: []jwk{{Kty: "RSA", Kid: "enc", Use: "enc"}, rsaJWK("rsa", "RS256", &rsaKey.PublicKey)}, want: []string{"rsa"}},
+		{name: "duplicate kid", keys: []jwk{rsaJWK("rsa", "RS256", &rsaKey.PublicKey), rsaJWK("rsa", "RS256", &rsaKey.PublicKey)}, err: true},
+		{name: "point off the curve", keys: []jwk{offCurve}, err: true},
+		{name: "unsupported curve", keys: []jwk{{Kty: "EC", Kid: "ec", Crv: "P-192"}}, err: true},
+		{name: "empty secret", keys: []jwk{{Kty: "oct", Kid: "hmac"}}, err: true},
+		{name: "unsupported key type", keys: []jwk{{Kty: "XYZ", Kid: "x"}}, err: true},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(map[string][]jwk{"keys": tt.keys})
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys, err := ParseJWKS(data)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidKey) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, ErrInvalidKey)
+			}
+			continue
+		}
+		var got []string
+		for _, key := range keys {
+			got = append(got, key.ID)
+		}
+		if err != nil || len(got) != len(tt.want) {
+			t.Errorf("%s: ParseJWKS = %v, %v, want %v", tt.name, got, err, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: ParseJWKS = %v, want %v", tt.name, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// Rotated keys replace the old ones, and a broken document keeps the keys already loaded
+func TestKeysetReload(t *testing.T) {
+	ctx := context.Background()
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeJWKS(t, filepath.Join(t.TempDir(), "jwks.json"), rsaJWK("old", "RS256", &oldKey.PublicKey))
+
+	ks := NewKeyset([]Key{{Material: []byte(testSecret)}}, path)
+	steps := []struct {
+		name     string
+		document func()
+		err      bool
+		want     []string
+	}{
+		{name: "initial load", document: func() {}, want: []string{"", "old"}},
+		{name: "rotation", document: func() { writeJWKS(t, path, rsaJWK("new", "RS256", &newKey.PublicKey)) }, want: []string{"", "new"}},
+		{name: "broken document", document: func() {
+			if err := os.WriteFile(path, []byte("{"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}, err: true, want: []string{"", "new"}},
+	}
+	for _, step := range steps {
+		step.document()
+		if err := ks.Reload(ctx); (err != nil) != step.err {
+			t.Errorf("%s: Reload error = %v, want error %v", step.name, err, step.err)
+		}
+		if ks.Len() != len(step.want) {
+			t.Errorf("%s: %d keys, want %d", step.name, ks.Len(), len(step.want))
+		}
+		for _, kid := range step.want {
+			if _, ok := ks.Lookup(kid); !ok {
+				t.Errorf("%s: kid %q is missing", step.name, kid)
+			}
+		}
+	}
+}
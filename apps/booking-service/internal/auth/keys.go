@@ -0,0 +1,248 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidKey = errors.New("invalid verification key")
+
+// Key verifies tokens whose kid header is ID; tokens without a kid use the key with an empty ID.
+// A key with an Algorithm only verifies tokens signed with that algorithm.
+type Key struct {
+	ID        string
+	Algorithm string
+	// Material is []byte for HMAC, *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey
+	Material any
+}
+
+// Keyset holds the verification keys. Keys loaded from a JWKS source are replaced on every
+// Reload, so rotating keys is a matter of publishing a new document; static keys always stay.
+type Keyset struct {
+	mu     sync.RWMutex
+	static []Key
+	source string
+	keys   map[string]Key
+}
+
+// NewKeyset returns a keyset of the static keys, plus those in the JWKS document at source
+// (a file path or http(s) URL) once it is loaded
+func NewKeyset(static []Key, source string) *Keyset {
+	ks := &Keyset{static: static, source: source}
+	ks.set(nil)
+	return ks
+}
+
+func (ks *Keyset) Lookup(kid string) (Key, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, ok := ks.keys[kid]
+	return key, ok
+}
+
+func (ks *Keyset) Len() int {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return len(ks.keys)
+}
+
+// Algorithms lists the algorithms pinned by the keys
+func (ks *Keyset) Algorithms() []string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	var algs []string
+	for _, key := range ks.keys {
+		if key.Algorithm != "" {
+			algs = append(algs, key.Algorithm)
+		}
+	}
+	return algs
+}
+
+func (ks *Keyset) set(loaded []Key) {
+	keys := make(map[string]Key, len(ks.static)+len(loaded))
+	for _, key := range loaded {
+		keys[key.ID] = key
+	}
+	for _, key := range ks.static {
+		keys[key.ID] = key
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.mu.Unlock()
+}
+
+// Reload replaces the keys from the JWKS source. On failure the current keys are kept.
+func (ks *Keyset) Reload(ctx context.Context) error {
+	if ks.source == "" {
+		return nil
+	}
+
+	data, err := readSource(ctx, ks.source)
+	if err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	ks.set(keys)
+	return nil
+}
+
+// Run reloads the keys every interval until ctx is cancelled
+func (ks *Keyset) Run(ctx context.Context, interval time.Duration) {
+	if ks.source == "" {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := ks.Reload(ctx); err != nil {
+			log.Printf("auth: reloading keys from %s: %v", ks.source, err)
+		}
+	}
+}
+
+func readSource(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", source, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS reads the signing keys of a JWKS document (RFC 7517). Keys meant for encryption
+// are skipped.
+func ParseJWKS(data []byte) ([]Key, error) {
+	var doc struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+
+	seen := map[string]bool{}
+	keys := make([]Key, 0, len(doc.Keys))
+	for _, k := range doc.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if seen[k.Kid] {
+			return nil, fmt.Errorf("%w: duplicate kid %q", ErrInvalidKey, k.Kid)
+		}
+		seen[k.Kid] = true
+
+		material, err := k.material()
+		if err != nil {
+			return nil, fmt.Errorf("%w: kid %q: %v", ErrInvalidKey, k.Kid, err)
+		}
+		keys = append(keys, Key{ID: k.Kid, Algorithm: k.Alg, Material: material})
+	}
+	return keys, nil
+}
+
+func (k jwk) material() (any, error) {
+	switch k.Kty {
+	case "oct":
+		secret, err := decodeSegment(k.K)
+		if err != nil || len(secret) == 0 {
+			return nil, errors.New("bad k")
+		}
+		return secret, nil
+
+	case "RSA":
+		n, errN := decodeSegment(k.N)
+		e, errE := decodeSegment(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return nil, errors.New("bad n or e")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := decodeSegment(k.X)
+		y, errY := decodeSegment(k.Y)
+		if errX != nil || errY != nil {
+			return nil, errors.New("bad x or y")
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if _, err := pub.ECDH(); err != nil {
+			return nil, errors.New("point is not on the curve")
+		}
+		return pub, nil
+
+	case "OKP":
+		x, err := decodeSegment(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("only Ed25519 OKP keys are supported")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported kty %q", k.Kty)
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
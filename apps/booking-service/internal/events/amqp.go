@@ -0,0 +1,110 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AMQPPublisher publishes persistent JSON messages to a durable topic exchange, matching
+// publishEvent in packages/shared. Connections are opened lazily and re-opened after failures.
+type AMQPPublisher struct {
+	url      string
+	exchange string
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	channel *amqp.Channel
+}
+
+func NewAMQPPublisher(url string, exchange string) *AMQPPublisher {
+	return &AMQPPublisher{url: url, exchange: exchange}
+}
+
+func (p *AMQPPublisher) Publish(ctx context.Context, routingKey string, messageID string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ch, err := p.ensureChannel()
+	if err != nil {
+		return err
+	}
+
+	confirmation, err := ch.PublishWithDeferredConfirmWithContext(ctx, p.exchange, routingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    messageID,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+	if err != nil {
+		p.reset()
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		p.reset()
+		return err
+	}
+	if !acked {
+		return errors.New("message was nacked by the broker")
+	}
+
+	return nil
+}
+
+func (p *AMQPPublisher) ensureChannel() (*amqp.Channel, error) {
+	if p.channel != nil && !p.channel.IsClosed() {
+		return p.channel, nil
+	}
+	p.reset()
+
+	conn, err := amqp.Dial(p.url)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := ch.ExchangeDeclare(p.exchange, "topic", true, false, false, false, nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// Publisher confirms let the relay mark events as published only once the broker has them
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	p.conn = conn
+	p.channel = ch
+	return ch, nil
+}
+
+func (p *AMQPPublisher) reset() {
+	if p.channel != nil {
+		p.channel.Close()
+		p.channel = nil
+	}
+	if p.conn != nil {
+		p.conn.Close()
+		p.conn = nil
+	}
+}
+
+func (p *AMQPPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.reset()
+	return nil
+}
@@ -0,0 +1,48 @@
+package events
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	BookingCreated     = "booking.created"
+	BookingConfirmed   = "booking.confirmed"
+	BookingDeclined    = "booking.declined"
+	BookingCancelled   = "booking.cancelled"
+	BookingCompleted   = "booking.completed"
+	BookingNoShow      = "booking.no_show"
+	BookingExpired     = "booking.expired"
+	BookingReminder    = "booking.reminder"
+	BookingRescheduled = "booking.rescheduled"
+	// BookingMeetingReady follows a confirmation or reschedule once the remote booking's room is open
+	BookingMeetingReady = "booking.meeting_ready"
+)
+
+// Types lists every event type the booking service publishes
+var Types = []string{
+	BookingCreated,
+	BookingConfirmed,
+	BookingDeclined,
+	BookingCancelled,
+	BookingCompleted,
+	BookingNoShow,
+	BookingExpired,
+	BookingReminder,
+	BookingRescheduled,
+	BookingMeetingReady,
+}
+
+// Event is the envelope published to the topic exchange. The event type doubles as the routing key.
+type Event struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	AggregateID string    `json:"aggregateId"`
+	OccurredAt  time.Time `json:"occurredAt"`
+	Data        any       `json:"data"`
+}
+
+type Publisher interface {
+	Publish(ctx context.Context, routingKey string, messageID string, body []byte) error
+	Close() error
+}
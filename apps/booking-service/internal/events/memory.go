@@ -0,0 +1,41 @@
+package events
+
+import (
+	"context"
+	"sync"
+)
+
+type Message struct {
+	RoutingKey string
+	MessageID  string
+	Body       []byte
+}
+
+// MemoryPublisher keeps published messages in memory, for tests and local development
+type MemoryPublisher struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, routingKey string, messageID string, body []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.messages = append(p.messages, Message{RoutingKey: routingKey, MessageID: messageID, Body: append([]byte(nil), body...)})
+	return nil
+}
+
+func (p *MemoryPublisher) Messages() []Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]Message(nil), p.messages...)
+}
+
+func (p *MemoryPublisher) Close() error {
+	return nil
+}
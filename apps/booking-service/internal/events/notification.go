@@ -0,0 +1,106 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NotificationPublisher hands events whose data is a notification (userId, type, title, body,
+// data, channels) to the notification service's internal POST /api/notifications/send, the way
+// sendNotification in packages/shared does. The service authenticates the call with the shared
+// INTERNAL_SERVICE_KEY.
+type NotificationPublisher struct {
+	url         string
+	internalKey string
+	client      *http.Client
+}
+
+func NewNotificationPublisher(baseURL string, internalKey string) *NotificationPublisher {
+	return &NotificationPublisher{
+		url:         strings.TrimSuffix(baseURL, "/") + "/api/notifications/send",
+		internalKey: internalKey,
+		client:      &http.Client{},
+	}
+}
+
+func (p *NotificationPublisher) Publish(ctx context.Context, routingKey string, messageID string, body []byte) error {
+	var event struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &event); err != nil || len(event.Data) == 0 {
+		return fmt.Errorf("%s event %s carries no notification", routingKey, messageID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(event.Data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Internal-Service-Key", p.internalKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("notification service answered %s: %s", resp.Status, bytes.TrimSpace(detail))
+	}
+	return nil
+}
+
+func (p *NotificationPublisher) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
+
+// RoutingPublisher sends each event to the publisher registered for its routing key
+type RoutingPublisher struct {
+	routes map[string]Publisher
+}
+
+func NewRoutingPublisher(routes map[string]Publisher) *RoutingPublisher {
+	return &RoutingPublisher{routes: routes}
+}
+
+// Routes lists the event types a publisher is registered for, for OutboxRelay.EventTypes
+func (p *RoutingPublisher) Routes() []string {
+	types := make([]string, 0, len(p.routes))
+	for _, t := range Types {
+		if _, ok := p.routes[t]; ok {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func (p *RoutingPublisher) Publish(ctx context.Context, routingKey string, messageID string, body []byte) error {
+	publisher, ok := p.routes[routingKey]
+	if !ok {
+		return fmt.Errorf("no publisher for %s events", routingKey)
+	}
+	return publisher.Publish(ctx, routingKey, messageID, body)
+}
+
+// Close closes every publisher once, however many event types it serves
+func (p *RoutingPublisher) Close() error {
+	closed := map[Publisher]bool{}
+	var firstErr error
+	for _, publisher := range p.routes {
+		if closed[publisher] {
+			continue
+		}
+		closed[publisher] = true
+		if err := publisher.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
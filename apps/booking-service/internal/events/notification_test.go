@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNotificationPublisher(t *testing.T) {
+	const key = "internal-key"
+	notification := `{"userId":"22222222-2222-2222-2222-222222222222","type":"booking_reminder","title":"Upcoming booking","body":"soon","data":{},"channels":["in_app"]}`
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		err    bool
+	}{
+		{name: "delivered", body: `{"id":"e1","type":"booking.reminder","data":` + notification + `}`, status: http.StatusOK},
+		{name: "rejected", body: `{"id":"e1","type":"booking.reminder","data":` + notification + `}`, status: http.StatusBadRequest, err: true},
+		{name: "unavailable", body: `{"id":"e1","type":"booking.reminder","data":` + notification + `}`, status: http.StatusServiceUnavailable, err: true},
+		{name: "no notification", body: `{"id":"e1","type":"booking.reminder"}`, status: http.StatusOK, err: true},
+	}
+	for _, tt := range tests {
+		var received string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/api/notifications/send" || r.Header.Get("X-Internal-Service-Key") != key {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			received = string(body)
+			w.WriteHeader(tt.status)
+		}))
+
+		p := NewNotificationPublisher(srv.URL+"/", key)
+		err := p.Publish(context.Background(), BookingReminder, "e1", []byte(tt.body))
+		srv.Close()
+
+		if (err != nil) != tt.err {
+			t.Errorf("%s: Publish error = %v, want error %v", tt.name, err, tt.err)
+			continue
+		}
+		if !tt.err && !jsonEqual(t, received, notification) {
+			t.Errorf("%s: sent %s, want the event's notification %s", tt.name, received, notification)
+		}
+	}
+}
+
+func TestRoutingPublisher(t *testing.T) {
+	amqp, notifier := NewMemoryPublisher(), NewMemoryPublisher()
+	p := NewRoutingPublisher(map[string]Publisher{BookingCreated: amqp, BookingCancelled: amqp, BookingReminder: notifier})
+
+	if got := strings.Join(p.Routes(), ","); got != "booking.created,booking.cancelled,booking.reminder" {
+		t.Fatalf("Routes = %s", got)
+	}
+	for _, key := range []string{BookingCreated, BookingReminder} {
+		if err := p.Publish(context.Background(), key, key, []byte("{}")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.Publish(context.Background(), BookingExpired, "e3", []byte("{}")); err == nil {
+		t.Fatal("published an event with no route")
+	}
+	if len(amqp.Messages()) != 1 || amqp.Messages()[0].RoutingKey != BookingCreated || len(notifier.Messages()) != 1 || notifier.Messages()[0].RoutingKey != BookingReminder {
+		t.Fatalf("routed %v and %v", amqp.Messages(), notifier.Messages())
+	}
+}
+
+func jsonEqual(t *testing.T, a, b string) bool {
+	t.Helper()
+	var va, vb any
+	if err := json.Unmarshal([]byte(a), &va); err != nil {
+		return false
+	}
+	if err := json.Unmarshal([]byte(b), &vb); err != nil {
+		t.Fatal(err)
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return string(ja) == string(jb)
+}
@@ -0,0 +1,123 @@
+package events
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"booking-service/internal/store"
+)
+
+// OutboxRelay moves events from the outbox to the broker. A batch of due events is leased in a
+// statement of its own, published outside any transaction, and the outcomes are recorded in a
+// second short one, so no database locks are held while the broker is slow. Events leased by
+// another relay are skipped, so several replicas can run a relay side by side. Delivery is at
+// least once: an event whose outcome could not be recorded is published again once its lease
+// runs out, and consumers deduplicate on the message ID.
+//
+// Failed publishes are retried with exponential backoff. After MaxAttempts the event is
+// dead-lettered: the relay logs an error and stops retrying it until it is requeued.
+type OutboxRelay struct {
+	store       store.Store
+	publisher   Publisher
+	Interval    time.Duration
+	BatchSize   int
+	MaxAttempts int
+	// Lease is how long a relay holds the events it claimed. Publishing stops before it runs out.
+	Lease time.Duration
+	// EventTypes limits the relay to events of these types; nil relays every event. Events of
+	// other types stay queued in the outbox.
+	EventTypes []string
+
+	now func() time.Time
+}
+
+func NewOutboxRelay(st store.Store, publisher Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		store:       st,
+		publisher:   publisher,
+		Interval:    2 * time.Second,
+		BatchSize:   50,
+		MaxAttempts: 10,
+		Lease:       2 * time.Minute,
+		now:         time.Now,
+	}
+}
+
+// publishTimeout bounds a single publish to the broker
+const publishTimeout = 10 * time.Second
+
+// Run relays events until ctx is cancelled
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.RelayBatch(ctx)
+			if err != nil {
+				log.Printf("outbox relay: %v", err)
+			}
+			// Keep draining while batches come back full
+			if err != nil || n < r.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayBatch publishes up to BatchSize due events and returns how many it attempted
+func (r *OutboxRelay) RelayBatch(ctx context.Context) (int, error) {
+	claimedAt := r.now()
+	lockedUntil := claimedAt.Add(r.Lease)
+	due, err := r.store.Outbox().Claim(ctx, claimedAt, lockedUntil, r.EventTypes, r.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	outcomes := make([]error, 0, len(due))
+	for _, e := range due {
+		// Leave the rest for the next claim rather than publish events another relay may own
+		if r.now().Add(publishTimeout).After(lockedUntil) {
+			break
+		}
+		publishCtx, cancel := context.WithTimeout(ctx, publishTimeout)
+		outcomes = append(outcomes, r.publisher.Publish(publishCtx, e.EventType, e.ID, e.Payload))
+		cancel()
+	}
+
+	err = r.store.WithTx(ctx, func(tx store.Store) error {
+		now := r.now()
+		for i, publishErr := range outcomes {
+			e := due[i]
+			var err error
+			switch {
+			case publishErr == nil:
+				err = tx.Outbox().MarkPublished(ctx, e.ID, now)
+			case e.Attempts+1 >= r.MaxAttempts:
+				log.Printf("outbox relay: ERROR dead-lettering %s event %s for %s %s after %d attempts: %v",
+					e.EventType, e.ID, e.AggregateType, e.AggregateID, e.Attempts+1, publishErr)
+				err = tx.Outbox().MarkDeadLettered(ctx, e.ID, publishErr.Error(), now)
+			default:
+				err = tx.Outbox().MarkFailed(ctx, e.ID, publishErr.Error(), now.Add(backoff(e.Attempts+1)))
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return len(outcomes), err
+}
+
+// backoff doubles the retry delay per attempt, starting at 5s and capped at 10 minutes
+func backoff(attempt int) time.Duration {
+	delay := 5 * time.Second << min(attempt-1, 8)
+	return min(delay, 10*time.Minute)
+}
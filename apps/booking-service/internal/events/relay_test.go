@@ -0,0 +1,140 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"booking-service/internal/store"
+	"booking-service/internal/store/memory"
+)
+
+// flakyPublisher fails while down is set and otherwise hands messages to a MemoryPublisher
+type flakyPublisher struct {
+	*MemoryPublisher
+	down bool
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, routingKey string, messageID string, body []byte) error {
+	if p.down {
+		return errors.New("broker unreachable")
+	}
+	return p.MemoryPublisher.Publish(ctx, routingKey, messageID, body)
+}
+
+// newTestRelay relays from an in-memory outbox holding the given event ids, created a second
+// apart in that order, on a clock the test moves by hand
+func newTestRelay(t *testing.T, ids ...string) (*OutboxRelay, *flakyPublisher, store.Store, *time.Time) {
+	t.Helper()
+	st := memory.New()
+	now := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+	for i, id := range ids {
+		created := now.Add(time.Duration(i-len(ids)) * time.Second)
+		err := st.Outbox().Add(context.Background(), &store.OutboxEvent{
+			ID: id, AggregateType: "booking", AggregateID: "b-" + id, EventType: "booking.created",
+			Payload: []byte(`{"id":"` + id + `"}`), NextAttemptAt: created, CreatedAt: created,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	publisher := &flakyPublisher{MemoryPublisher: NewMemoryPublisher()}
+	relay := NewOutboxRelay(st, publisher)
+	relay.now = func() time.Time { return now }
+	return relay, publisher, st, &now
+}
+
+func messageIDs(p *MemoryPublisher) []string {
+	var ids []string
+	for _, m := range p.Messages() {
+		ids = append(ids, m.MessageID)
+	}
+	return ids
+}
+
+func TestRelayBatchPublishesOldestFirst(t *testing.T) {
+	ctx := context.Background()
+	relay, publisher, _, _ := newTestRelay(t, "e1", "e2", "e3")
+	relay.BatchSize = 2
+
+	for _, want := range []int{2, 1, 0} {
+		n, err := relay.RelayBatch(ctx)
+		if err != nil || n != want {
+			t.Fatalf("RelayBatch = %d, %v, want %d", n, err, want)
+		}
+	}
+	if got := messageIDs(publisher.MemoryPublisher); !slices.Equal(got, []string{"e1", "e2", "e3"}) {
+		t.Fatalf("published %v, want e1, e2, e3 once each", got)
+	}
+}
+
+func TestRelayBatchSkipsLeasedEvents(t *testing.T) {
+	ctx := context.Background()
+	relay, publisher, st, now := newTestRelay(t, "e1", "e2")
+
+	// Another relay claimed e1 and died before recording the outcome
+	if _, err := st.Outbox().Claim(ctx, *now, now.Add(relay.Lease), nil, 1); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := relay.RelayBatch(ctx); err != nil || n != 1 {
+		t.Fatalf("RelayBatch = %d, %v, want only the unleased event", n, err)
+	}
+
+	*now = now.Add(relay.Lease)
+	if n, err := relay.RelayBatch(ctx); err != nil || n != 1 {
+		t.Fatalf("RelayBatch after the lease = %d, %v, want the abandoned event", n, err)
+	}
+	if got := messageIDs(publisher.MemoryPublisher); !slices.Equal(got, []string{"e2", "e1"}) {
+		t.Fatalf("published %v, want e2 then e1", got)
+	}
+}
+
+func TestRelayBatchDeadLettersAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	relay, publisher, st, now := newTestRelay(t, "e1")
+	relay.MaxAttempts = 3
+	publisher.down = true
+
+	if n, err := relay.RelayBatch(ctx); err != nil || n != 1 {
+		t.Fatalf("RelayBatch = %d, %v, want 1", n, err)
+	}
+	for attempt := 2; attempt <= relay.MaxAttempts; attempt++ {
+		if n, _ := relay.RelayBatch(ctx); n != 0 {
+			t.Fatalf("attempt %d was made before the backoff ran out", attempt)
+		}
+		*now = now.Add(backoff(attempt - 1))
+		if n, err := relay.RelayBatch(ctx); err != nil || n != 1 {
+			t.Fatalf("attempt %d: RelayBatch = %d, %v, want 1", attempt, n, err)
+		}
+	}
+
+	dead, err := st.Outbox().ListDeadLettered(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].ID != "e1" || dead[0].Attempts != relay.MaxAttempts || dead[0].LastError == nil {
+		t.Fatalf("dead letters = %+v, want e1 after %d attempts", dead, relay.MaxAttempts)
+	}
+	*now = now.Add(time.Hour)
+	if n, _ := relay.RelayBatch(ctx); n != 0 {
+		t.Fatalf("dead-lettered event was retried")
+	}
+
+	// Requeued once the broker is back, it goes out on the next batch
+	publisher.down = false
+	if err := st.Outbox().Requeue(ctx, "e1", *now); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := relay.RelayBatch(ctx); err != nil || n != 1 {
+		t.Fatalf("RelayBatch after requeue = %d, %v, want 1", n, err)
+	}
+	if got := messageIDs(publisher.MemoryPublisher); !slices.Equal(got, []string{"e1"}) {
+		t.Fatalf("published %v, want e1", got)
+	}
+	if err := st.Outbox().Requeue(ctx, "e1", *now); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("requeueing a published event error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+	"booking-service/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultBlockedSlotWindow is how far ahead blocked slots are listed when no range is given
+const defaultBlockedSlotWindow = 90 * 24 * time.Hour
+
+// AdminHandler serves the support staff API. Routes must be gated with middleware.RequireRole.
+type AdminHandler struct {
+	bookings     *services.BookingService
+	availability *services.AvailabilityService
+}
+
+func NewAdminHandler(bookings *services.BookingService, availability *services.AvailabilityService) *AdminHandler {
+	return &AdminHandler{bookings: bookings, availability: availability}
+}
+
+func (h *AdminHandler) SearchBookings(c *gin.Context) {
+	var query models.AdminBookingsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	bookings, nextCursor, err := h.bookings.AdminSearchBookings(c.Request.Context(), &query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var next any
+	if nextCursor != "" {
+		next = nextCursor
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": bookings, "nextCursor": next})
+}
+
+func (h *AdminHandler) GetBooking(c *gin.Context) {
+	booking, err := h.bookings.AdminGetBooking(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondBooking(c, http.StatusOK, booking)
+}
+
+func (h *AdminHandler) GetBookingHistory(c *gin.Context) {
+	history, err := h.bookings.AdminGetBookingHistory(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": history})
+}
+
+func (h *AdminHandler) CancelBooking(c *gin.Context) {
+	staffID := c.GetString("userId")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminCancelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	booking, err := h.bookings.AdminCancelBooking(c.Request.Context(), c.Param("id"), staffID, version, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondBooking(c, http.StatusOK, booking)
+}
+
+func (h *AdminHandler) OverrideStatus(c *gin.Context) {
+	staffID := c.GetString("userId")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req models.AdminStatusOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	booking, err := h.bookings.AdminOverrideStatus(c.Request.Context(), c.Param("id"), staffID, version, req.Status, req.Note)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondBooking(c, http.StatusOK, booking)
+}
+
+// ListDeadLetters lists the outbox events the relay gave up publishing, up to the limit query
+// parameter (default 50)
+func (h *AdminHandler) ListDeadLetters(c *gin.Context) {
+	var query struct {
+		Limit int `form:"limit" binding:"omitempty,min=1,max=200"`
+	}
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = 50
+	}
+
+	letters, err := h.bookings.AdminListDeadLetters(c.Request.Context(), query.Limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": letters})
+}
+
+func (h *AdminHandler) RequeueDeadLetter(c *gin.Context) {
+	if err := h.bookings.AdminRequeueDeadLetter(c.Request.Context(), c.Param("id")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Event requeued"})
+}
+
+// GetWorkerCalendar shows a worker's availability and the slots they blocked between the from
+// and to query parameters (RFC 3339), by default the next 90 days
+func (h *AdminHandler) GetWorkerCalendar(c *gin.Context) {
+	from, to := time.Now(), time.Time{}
+	if !parseTimeQuery(c, "from", &from) || !parseTimeQuery(c, "to", &to) {
+		return
+	}
+	if to.IsZero() {
+		to = from.Add(defaultBlockedSlotWindow)
+	}
+
+	calendar, err := h.availability.AdminGetWorkerCalendar(c.Request.Context(), c.Param("workerId"), from, to)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": calendar})
+}
+
+// parseTimeQuery reads an optional RFC 3339 query parameter into dst. A malformed value is
+// answered with 400 and ok is false.
+func parseTimeQuery(c *gin.Context, name string, dst *time.Time) (ok bool) {
+	value := c.Query(name)
+	if value == "" {
+		return true
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.Error(apperr.BadRequest("INVALID_DATE", name+" must be an RFC 3339 timestamp"))
+		return false
+	}
+	*dst = parsed
+	return true
+}
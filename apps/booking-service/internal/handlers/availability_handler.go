@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"time"
 
+	"booking-service/internal/apperr"
 	"booking-service/internal/models"
 	"booking-service/internal/services"
 
@@ -24,7 +25,7 @@ func (h *AvailabilityHandler) GetWorkerAvailability(c *gin.Context) {
 
 	availability, err := h.service.GetWorkerAvailability(c.Request.Context(), workerID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "FETCH_FAILED", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
@@ -34,26 +35,78 @@ func (h *AvailabilityHandler) GetWorkerAvailability(c *gin.Context) {
 func (h *AvailabilityHandler) GetAvailableSlots(c *gin.Context) {
 	workerID := c.Param("workerId")
 	dateStr := c.Query("date")
-	durationStr := c.DefaultQuery("duration", "60")
 
 	date, err := time.Parse("2006-01-02", dateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": gin.H{"code": "INVALID_DATE", "message": "Invalid date format"}})
+		c.Error(apperr.BadRequest("INVALID_DATE", "Invalid date format"))
 		return
 	}
 
-	var duration int
-	if _, err := fmt.Sscanf(durationStr, "%d", &duration); err != nil {
-		duration = 60
+	displayLoc, ok := parseDisplayLocation(c)
+	if !ok {
+		return
+	}
+
+	slots, loc, err := h.service.GetAvailableSlots(c.Request.Context(), workerID, c.GetString("userId"), date, parseDuration(c), displayLoc)
+	if err != nil {
+		c.Error(err)
+		return
 	}
 
-	slots, err := h.service.GetAvailableSlots(c.Request.Context(), workerID, date, duration)
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": slots, "timezone": loc.String()})
+}
+
+func (h *AvailabilityHandler) GetAvailableSlotsRange(c *gin.Context) {
+	workerID := c.Param("workerId")
+
+	from, err := time.Parse("2006-01-02", c.Query("from"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "FETCH_FAILED", "message": err.Error()}})
+		c.Error(apperr.BadRequest("INVALID_DATE", "Invalid from date format"))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": slots})
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		c.Error(apperr.BadRequest("INVALID_DATE", "Invalid to date format"))
+		return
+	}
+
+	displayLoc, ok := parseDisplayLocation(c)
+	if !ok {
+		return
+	}
+
+	days, loc, err := h.service.GetAvailableSlotsRange(c.Request.Context(), workerID, c.GetString("userId"), from, to, parseDuration(c), displayLoc)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": days, "timezone": loc.String()})
+}
+
+func parseDuration(c *gin.Context) int {
+	var duration int
+	if _, err := fmt.Sscanf(c.DefaultQuery("duration", "60"), "%d", &duration); err != nil || duration <= 0 {
+		duration = 60
+	}
+	return duration
+}
+
+// parseDisplayLocation reads the optional IANA timezone the caller wants slots rendered in.
+// A nil location means the worker's own timezone.
+func parseDisplayLocation(c *gin.Context) (*time.Location, bool) {
+	tz := c.Query("tz")
+	if tz == "" {
+		return nil, true
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		c.Error(apperr.BadRequest("INVALID_TIMEZONE", "Invalid timezone"))
+		return nil, false
+	}
+	return loc, true
 }
 
 func (h *AvailabilityHandler) UpdateAvailability(c *gin.Context) {
@@ -61,43 +114,154 @@ func (h *AvailabilityHandler) UpdateAvailability(c *gin.Context) {
 	workerID := c.Param("workerId")
 
 	if userID != workerID {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": gin.H{"code": "FORBIDDEN", "message": "Not authorized"}})
+		c.Error(apperr.Forbidden("FORBIDDEN", "Not authorized"))
 		return
 	}
 
 	var req []models.AvailabilitySlot
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": gin.H{"code": "VALIDATION_ERROR", "message": err.Error()}})
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
 		return
 	}
 
 	err := h.service.UpdateAvailability(c.Request.Context(), workerID, req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "UPDATE_FAILED", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Availability updated"})
 }
 
+func (h *AvailabilityHandler) GetSchedulingRules(c *gin.Context) {
+	workerID := c.Param("workerId")
+
+	rules, err := h.service.GetSchedulingRules(c.Request.Context(), workerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": rules})
+}
+
+func (h *AvailabilityHandler) UpdateSchedulingRules(c *gin.Context) {
+	userID := c.GetString("userId")
+	workerID := c.Param("workerId")
+
+	if userID != workerID {
+		c.Error(apperr.Forbidden("FORBIDDEN", "Not authorized"))
+		return
+	}
+
+	// Start from the current rules so a partial body only changes the fields it names
+	rules, err := h.service.GetSchedulingRules(c.Request.Context(), workerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := c.ShouldBindJSON(rules); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	if err := h.service.UpdateSchedulingRules(c.Request.Context(), workerID, rules); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": rules})
+}
+
+func (h *AvailabilityHandler) GetCancellationPolicy(c *gin.Context) {
+	workerID := c.Param("workerId")
+
+	policy, err := h.service.GetCancellationPolicy(c.Request.Context(), workerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": policy})
+}
+
+func (h *AvailabilityHandler) UpdateCancellationPolicy(c *gin.Context) {
+	userID := c.GetString("userId")
+	workerID := c.Param("workerId")
+
+	if userID != workerID {
+		c.Error(apperr.Forbidden("FORBIDDEN", "Not authorized"))
+		return
+	}
+
+	var policy models.CancellationPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	if err := h.service.UpdateCancellationPolicy(c.Request.Context(), workerID, &policy); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": policy})
+}
+
+func (h *AvailabilityHandler) GetRateCard(c *gin.Context) {
+	workerID := c.Param("workerId")
+
+	card, err := h.service.GetRateCard(c.Request.Context(), workerID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": card})
+}
+
+func (h *AvailabilityHandler) UpdateRateCard(c *gin.Context) {
+	userID := c.GetString("userId")
+	workerID := c.Param("workerId")
+
+	if userID != workerID {
+		c.Error(apperr.Forbidden("FORBIDDEN", "Not authorized"))
+		return
+	}
+
+	var card models.RateCard
+	if err := c.ShouldBindJSON(&card); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	if err := h.service.UpdateRateCard(c.Request.Context(), workerID, &card); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": card})
+}
+
 func (h *AvailabilityHandler) BlockTimeSlot(c *gin.Context) {
 	userID := c.GetString("userId")
 	workerID := c.Param("workerId")
 
 	if userID != workerID {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": gin.H{"code": "FORBIDDEN", "message": "Not authorized"}})
+		c.Error(apperr.Forbidden("FORBIDDEN", "Not authorized"))
 		return
 	}
 
 	var req models.BlockedSlot
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": gin.H{"code": "VALIDATION_ERROR", "message": err.Error()}})
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
 		return
 	}
 
 	slot, err := h.service.BlockTimeSlot(c.Request.Context(), workerID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "BLOCK_FAILED", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
@@ -110,13 +274,13 @@ func (h *AvailabilityHandler) UnblockTimeSlot(c *gin.Context) {
 	slotID := c.Param("slotId")
 
 	if userID != workerID {
-		c.JSON(http.StatusForbidden, gin.H{"success": false, "error": gin.H{"code": "FORBIDDEN", "message": "Not authorized"}})
+		c.Error(apperr.Forbidden("FORBIDDEN", "Not authorized"))
 		return
 	}
 
 	err := h.service.UnblockTimeSlot(c.Request.Context(), workerID, slotID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "UNBLOCK_FAILED", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
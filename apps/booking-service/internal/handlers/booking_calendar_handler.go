@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+// CreateCalendarFeed issues a subscribable feed URL. Calling it again rotates the token and
+// invalidates the previous URL.
+func (h *BookingHandler) CreateCalendarFeed(c *gin.Context) {
+	userID := c.GetString("userId")
+	role := c.Query("role") // "worker" or "client", defaults to the caller's role
+	if role == "" {
+		role = c.GetString("role")
+	}
+	if role != "worker" {
+		role = "client"
+	}
+
+	token, err := h.service.CreateCalendarFeed(c.Request.Context(), userID, role)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	path := "/api/calendar/" + token + ".ics"
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": gin.H{"url": h.publicOrigin(c) + path, "role": role}})
+}
+
+func (h *BookingHandler) RevokeCalendarFeed(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	if err := h.service.RevokeCalendarFeed(c.Request.Context(), userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Calendar feed revoked"})
+}
+
+// GetCalendarFeed serves the feed itself. It is public: calendar clients can't send a bearer
+// token, so the token in the URL is the credential.
+func (h *BookingHandler) GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	feed, err := h.service.GetCalendarFeed(c.Request.Context(), token)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, calendarContentType, feed)
+}
+
+func (h *BookingHandler) getBookingICS(c *gin.Context, bookingID string, userID string) {
+	ics, err := h.service.GetBookingICS(c.Request.Context(), bookingID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="booking-`+bookingID+`.ics"`)
+	c.Data(http.StatusOK, calendarContentType, ics)
+}
+
+func wantsICS(c *gin.Context) bool {
+	return c.Query("format") == "ics" || strings.Contains(c.GetHeader("Accept"), "text/calendar")
+}
+
+// publicOrigin is the configured public base URL. Without one it falls back to the address the
+// request reached this server on, which is only right when nothing proxies it.
+func (h *BookingHandler) publicOrigin(c *gin.Context) string {
+	if h.PublicBaseURL != "" {
+		return strings.TrimSuffix(h.PublicBaseURL, "/")
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
@@ -1,15 +1,24 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
-	"github.com/gin-gonic/gin"
-	"booking-service/internal/services"
+	"booking-service/internal/apperr"
 	"booking-service/internal/models"
+	"booking-service/internal/services"
+
+	"github.com/gin-gonic/gin"
 )
 
 type BookingHandler struct {
 	service *services.BookingService
+	// PublicBaseURL is the origin clients reach the API on, such as https://api.example.com.
+	// Calendar feed URLs are built on it rather than on headers a client or proxy could forge.
+	PublicBaseURL string
 }
 
 func NewBookingHandler(service *services.BookingService) *BookingHandler {
@@ -21,104 +30,290 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 
 	var req models.CreateBookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": gin.H{"code": "VALIDATION_ERROR", "message": err.Error()}})
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
 		return
 	}
 
 	booking, err := h.service.CreateBooking(c.Request.Context(), userID, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "CREATE_FAILED", "message": err.Error()}})
+		c.Error(err)
+		return
+	}
+
+	respondBooking(c, http.StatusCreated, booking)
+}
+
+func (h *BookingHandler) QuoteBooking(c *gin.Context) {
+	var req models.QuoteRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	quote, err := h.service.QuoteBooking(c.Request.Context(), &req)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"success": true, "data": booking})
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": quote})
 }
 
 func (h *BookingHandler) GetUserBookings(c *gin.Context) {
 	userID := c.GetString("userId")
-	role := c.Query("role") // "worker" or "client"
 
-	bookings, err := h.service.GetUserBookings(c.Request.Context(), userID, role)
+	var query models.ListBookingsQuery // role is "worker" or "client"
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	bookings, nextCursor, err := h.service.ListBookings(c.Request.Context(), userID, &query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "FETCH_FAILED", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": bookings})
+	// Clients that don't page keep getting the plain list they always did
+	if !services.PagedBookingList(&query) {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": bookings})
+		return
+	}
+
+	var next any
+	if nextCursor != "" {
+		next = nextCursor
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": bookings, "nextCursor": next})
 }
 
 func (h *BookingHandler) GetBooking(c *gin.Context) {
 	userID := c.GetString("userId")
 	bookingID := c.Param("id")
 
+	if wantsICS(c) {
+		h.getBookingICS(c, bookingID, userID)
+		return
+	}
+
 	booking, err := h.service.GetBookingByID(c.Request.Context(), bookingID, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"success": false, "error": gin.H{"code": "NOT_FOUND", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": booking})
+	respondBooking(c, http.StatusOK, booking)
+}
+
+func (h *BookingHandler) GetBookingHistory(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+
+	history, err := h.service.GetBookingHistory(c.Request.Context(), bookingID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": history})
 }
 
 func (h *BookingHandler) UpdateBooking(c *gin.Context) {
 	userID := c.GetString("userId")
 	bookingID := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
 	var req models.UpdateBookingRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": gin.H{"code": "VALIDATION_ERROR", "message": err.Error()}})
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
 		return
 	}
 
-	booking, err := h.service.UpdateBooking(c.Request.Context(), bookingID, userID, &req)
+	booking, err := h.service.UpdateBooking(c.Request.Context(), bookingID, userID, version, &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "UPDATE_FAILED", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": booking})
+	respondBooking(c, http.StatusOK, booking)
 }
 
 func (h *BookingHandler) ConfirmBooking(c *gin.Context) {
 	userID := c.GetString("userId")
 	bookingID := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	booking, err := h.service.ConfirmBooking(c.Request.Context(), bookingID, userID, version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondBooking(c, http.StatusOK, booking)
+}
+
+func (h *BookingHandler) DeclineBooking(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	booking, err := h.service.DeclineBooking(c.Request.Context(), bookingID, userID, version, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondBooking(c, http.StatusOK, booking)
+}
+
+func (h *BookingHandler) StartBooking(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
-	booking, err := h.service.ConfirmBooking(c.Request.Context(), bookingID, userID)
+	booking, err := h.service.StartBooking(c.Request.Context(), bookingID, userID, version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "CONFIRM_FAILED", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": booking})
+	respondBooking(c, http.StatusOK, booking)
 }
 
 func (h *BookingHandler) CancelBooking(c *gin.Context) {
 	userID := c.GetString("userId")
 	bookingID := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
 	var req struct {
 		Reason string `json:"reason"`
 	}
-	c.ShouldBindJSON(&req)
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
 
-	booking, err := h.service.CancelBooking(c.Request.Context(), bookingID, userID, req.Reason)
+	booking, err := h.service.CancelBooking(c.Request.Context(), bookingID, userID, version, req.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "CANCEL_FAILED", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": booking})
+	respondBooking(c, http.StatusOK, booking)
+}
+
+func (h *BookingHandler) PreviewCancellation(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+
+	outcome, err := h.service.PreviewCancellation(c.Request.Context(), bookingID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": outcome})
 }
 
 func (h *BookingHandler) CompleteBooking(c *gin.Context) {
 	userID := c.GetString("userId")
 	bookingID := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
 
-	booking, err := h.service.CompleteBooking(c.Request.Context(), bookingID, userID)
+	booking, err := h.service.CompleteBooking(c.Request.Context(), bookingID, userID, version)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "error": gin.H{"code": "COMPLETE_FAILED", "message": err.Error()}})
+		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "data": booking})
+	respondBooking(c, http.StatusOK, booking)
+}
+
+func (h *BookingHandler) MarkNoShow(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if !bindOptionalJSON(c, &req) {
+		return
+	}
+
+	booking, err := h.service.MarkNoShow(c.Request.Context(), bookingID, userID, version, req.Reason)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondBooking(c, http.StatusOK, booking)
+}
+
+// bindOptionalJSON binds the request body into req, which may be left out altogether. It reports
+// whether the handler should go on; a malformed body has been answered with a validation error.
+func bindOptionalJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil && !errors.Is(err, io.EOF) {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return false
+	}
+	return true
+}
+
+// respondBooking sends a single booking with its version as the ETag, for use in If-Match
+func respondBooking(c *gin.Context, status int, booking *models.Booking) {
+	c.Header("ETag", bookingETag(booking))
+	c.JSON(status, gin.H{"success": true, "data": booking})
+}
+
+func bookingETag(b *models.Booking) string {
+	return `"` + strconv.Itoa(b.Version) + `"`
+}
+
+// ifMatchVersion reads the booking version a write is conditional on from If-Match. Without the
+// header, or with "*", the version is 0 and the write is unconditional. A malformed header is
+// answered with 400 and ok is false.
+func ifMatchVersion(c *gin.Context) (version int, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	tag := strings.TrimPrefix(header, "W/")
+	unquoted, err := strconv.Unquote(tag)
+	if err == nil && strings.HasPrefix(tag, `"`) {
+		version, err = strconv.Atoi(unquoted)
+	}
+	if err != nil || version <= 0 {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", "If-Match must be a single booking ETag such as \"3\""))
+		return 0, false
+	}
+	return version, true
 }
@@ -0,0 +1,381 @@
+package handlers_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"booking-service/internal/auth"
+	"booking-service/internal/handlers"
+	"booking-service/internal/middleware"
+	"booking-service/internal/models"
+	"booking-service/internal/services"
+	"booking-service/internal/store/memory"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testSecret    = "test-secret"
+	publicBaseURL = "https://api.example.com"
+	workerID      = "11111111-1111-1111-1111-111111111111"
+	clientID      = "22222222-2222-2222-2222-222222222222"
+	otherID       = "33333333-3333-3333-3333-333333333333"
+)
+
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Error   *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type testServer struct {
+	t      *testing.T
+	router *gin.Engine
+}
+
+// newTestServer routes the booking endpoints like cmd/server does, against an in-memory store,
+// with a worker who is available 09:00-17:00 UTC every day
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	st := memory.New()
+	verifier, err := auth.NewVerifier(context.Background(), auth.Config{Secret: testSecret})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bookingHandler := handlers.NewBookingHandler(services.NewBookingService(st))
+	bookingHandler.PublicBaseURL = publicBaseURL + "/"
+	availabilityHandler := handlers.NewAvailabilityHandler(services.NewAvailabilityService(st))
+	idempotency := middleware.NewIdempotency(st.Idempotency())
+
+	r := gin.New()
+	r.Use(middleware.Errors())
+	r.Use(middleware.UUIDParams("id", "holdId", "seriesId", "requestId", "workerId", "slotId"))
+	bookings := r.Group("/api/bookings", middleware.AuthMiddleware(verifier))
+	bookings.POST("", idempotency.Handler(), bookingHandler.CreateBooking)
+	bookings.GET("", bookingHandler.GetUserBookings)
+	bookings.POST("/calendar-feed", bookingHandler.CreateCalendarFeed)
+	bookings.GET("/:id", bookingHandler.GetBooking)
+	bookings.POST("/:id/confirm", idempotency.Handler(), bookingHandler.ConfirmBooking)
+	bookings.POST("/:id/cancel", idempotency.Handler(), bookingHandler.CancelBooking)
+	bookings.POST("/:id/reschedule", bookingHandler.ProposeReschedule)
+	bookings.POST("/:id/reschedule/:requestId/accept", bookingHandler.AcceptReschedule)
+	availability := r.Group("/api/availability", middleware.AuthMiddleware(verifier))
+	availability.PUT("/worker/:workerId", availabilityHandler.UpdateAvailability)
+	availability.PUT("/worker/:workerId/rate-card", availabilityHandler.UpdateRateCard)
+
+	s := &testServer{t: t, router: r}
+	slots := make([]models.AvailabilitySlot, 0, 7)
+	for day := range 7 {
+		slots = append(slots, models.AvailabilitySlot{DayOfWeek: day, StartTime: "09:00", EndTime: "17:00", IsRecurring: true})
+	}
+	s.expect(s.do(http.MethodPut, "/api/availability/worker/"+workerID, workerID, slots), http.StatusOK)
+	s.expect(s.do(http.MethodPut, "/api/availability/worker/"+workerID+"/rate-card", workerID, gin.H{"hourlyRate": 40}), http.StatusOK)
+	return s
+}
+
+// do sends body as JSON on behalf of userID; headers come in name, value pairs
+func (s *testServer) do(method, path, userID string, body any, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			s.t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token(s.t, userID))
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// expect fails the test unless the response has the status, and decodes its data into out
+func (s *testServer) expect(w *httptest.ResponseRecorder, status int, out ...any) envelope {
+	s.t.Helper()
+	var env envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		s.t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+	if w.Code != status {
+		s.t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	for _, o := range out {
+		if err := json.Unmarshal(env.Data, o); err != nil {
+			s.t.Fatalf("decoding data %s: %v", env.Data, err)
+		}
+	}
+	return env
+}
+
+func (s *testServer) createBooking(start time.Time, headers ...string) *models.Booking {
+	s.t.Helper()
+	booking := &models.Booking{}
+	s.expect(s.do(http.MethodPost, "/api/bookings", clientID, bookingRequest(start), headers...), http.StatusCreated, booking)
+	return booking
+}
+
+func token(t *testing.T, userID string) string {
+	t.Helper()
+	now := time.Now()
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, auth.Claims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}).SignedString([]byte(testSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+// nextWeek is hour o'clock UTC a week from today, well within the default booking window
+func nextWeek(hour int) time.Time {
+	day := time.Now().UTC().AddDate(0, 0, 7)
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, time.UTC)
+}
+
+func bookingRequest(start time.Time) gin.H {
+	return gin.H{"workerId": workerID, "title": "Session", "startTime": start, "endTime": start.Add(time.Hour)}
+}
+
+func TestBookingLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	start := nextWeek(10)
+
+	w := s.do(http.MethodPost, "/api/bookings", clientID, bookingRequest(start))
+	booking := &models.Booking{}
+	s.expect(w, http.StatusCreated, booking)
+	if booking.Status != models.BookingStatusPending || booking.TotalAmount.String() != "40" {
+		t.Fatalf("created booking = %s for %s, want pending for 40", booking.Status, booking.TotalAmount)
+	}
+	if etag := w.Header().Get("ETag"); etag != `"1"` {
+		t.Fatalf("ETag = %s, want \"1\"", etag)
+	}
+
+	s.expect(s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/confirm", workerID, nil, "If-Match", `"1"`), http.StatusOK, booking)
+	if booking.Status != models.BookingStatusConfirmed || booking.Version != 2 {
+		t.Fatalf("confirmed booking = %s at version %d, want confirmed at version 2", booking.Status, booking.Version)
+	}
+
+	// The confirmed booking holds the worker's time
+	env := s.expect(s.do(http.MethodPost, "/api/bookings", otherID, bookingRequest(start.Add(30*time.Minute))), http.StatusConflict)
+	if env.Error == nil || env.Error.Code != "SLOT_UNAVAILABLE" {
+		t.Fatalf("overlapping booking error = %+v, want SLOT_UNAVAILABLE", env.Error)
+	}
+
+	s.expect(s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/cancel", clientID, gin.H{"reason": "ill"}, "If-Match", `"2"`), http.StatusOK, booking)
+	if booking.Status != models.BookingStatusCancelled {
+		t.Fatalf("cancelled booking status = %s", booking.Status)
+	}
+
+	// Cancelling frees the slot again
+	s.createBooking(start.Add(30 * time.Minute))
+}
+
+func TestAcceptReschedule(t *testing.T) {
+	s := newTestServer(t)
+	booking := s.createBooking(nextWeek(10))
+	s.expect(s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/confirm", workerID, nil), http.StatusOK, booking)
+
+	newStart := nextWeek(14)
+	proposal := &models.RescheduleRequest{}
+	s.expect(s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/reschedule", clientID, gin.H{"startTime": newStart, "endTime": newStart.Add(2 * time.Hour)}), http.StatusCreated, proposal)
+
+	// Only the counterparty can accept
+	s.expect(s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/reschedule/"+proposal.ID+"/accept", clientID, nil), http.StatusForbidden)
+
+	w := s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/reschedule/"+proposal.ID+"/accept", workerID, nil)
+	s.expect(w, http.StatusOK, booking)
+	if !booking.StartTime.Equal(newStart) || booking.Duration != 120 || booking.TotalAmount.String() != "80" {
+		t.Fatalf("rescheduled booking starts %s for %d minutes at %s, want %s for 120 minutes at 80", booking.StartTime, booking.Duration, booking.TotalAmount, newStart)
+	}
+	if etag := w.Header().Get("ETag"); etag != `"3"` {
+		t.Fatalf("ETag = %s, want \"3\"", etag)
+	}
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	s := newTestServer(t)
+	start := nextWeek(10)
+
+	first := s.do(http.MethodPost, "/api/bookings", clientID, bookingRequest(start), "Idempotency-Key", "create-1")
+	s.expect(first, http.StatusCreated)
+	retry := s.do(http.MethodPost, "/api/bookings", clientID, bookingRequest(start), "Idempotency-Key", "create-1")
+	s.expect(retry, http.StatusCreated)
+
+	if retry.Body.String() != first.Body.String() {
+		t.Fatalf("replayed body = %s, want %s", retry.Body.String(), first.Body.String())
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("retry was not marked as replayed")
+	}
+	for _, name := range []string{"ETag", "Content-Type"} {
+		if got, want := retry.Header().Get(name), first.Header().Get(name); got != want {
+			t.Fatalf("replayed %s = %q, want %q", name, got, want)
+		}
+	}
+
+	var bookings []*models.Booking
+	s.expect(s.do(http.MethodGet, "/api/bookings?role=client", clientID, nil), http.StatusOK, &bookings)
+	if len(bookings) != 1 {
+		t.Fatalf("client has %d bookings, want 1", len(bookings))
+	}
+
+	// The same key for a different request is refused rather than replayed
+	env := s.expect(s.do(http.MethodPost, "/api/bookings", clientID, bookingRequest(start.Add(2*time.Hour)), "Idempotency-Key", "create-1"), http.StatusUnprocessableEntity)
+	if env.Error == nil || env.Error.Code != "IDEMPOTENCY_KEY_MISMATCH" {
+		t.Fatalf("reused key error = %+v, want IDEMPOTENCY_KEY_MISMATCH", env.Error)
+	}
+}
+
+func TestIfMatchMismatch(t *testing.T) {
+	s := newTestServer(t)
+	booking := s.createBooking(nextWeek(10))
+
+	env := s.expect(s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/confirm", workerID, nil, "If-Match", `"5"`), http.StatusPreconditionFailed)
+	if env.Error == nil || env.Error.Code != "PRECONDITION_FAILED" {
+		t.Fatalf("stale If-Match error = %+v, want PRECONDITION_FAILED", env.Error)
+	}
+	s.expect(s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/confirm", workerID, nil, "If-Match", "5"), http.StatusBadRequest)
+
+	s.expect(s.do(http.MethodGet, "/api/bookings/"+booking.ID, clientID, nil), http.StatusOK, booking)
+	if booking.Status != models.BookingStatusPending || booking.Version != 1 {
+		t.Fatalf("booking = %s at version %d after refused writes, want pending at version 1", booking.Status, booking.Version)
+	}
+}
+
+func TestRequiresToken(t *testing.T) {
+	s := newTestServer(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/bookings", nil)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+
+	env := s.expect(w, http.StatusUnauthorized)
+	if env.Error == nil || env.Error.Code != "UNAUTHORIZED" {
+		t.Fatalf("error = %+v, want UNAUTHORIZED", env.Error)
+	}
+}
+
+func TestListBookingsRejectsUnknownRole(t *testing.T) {
+	s := newTestServer(t)
+
+	env := s.expect(s.do(http.MethodGet, "/api/bookings?role=admin", clientID, nil), http.StatusBadRequest)
+	if env.Error == nil || env.Error.Code != "VALIDATION_ERROR" {
+		t.Fatalf("error = %+v, want VALIDATION_ERROR", env.Error)
+	}
+}
+
+// IDs that are not UUIDs cannot name anything, so they are answered without reaching the store
+func TestMalformedIDs(t *testing.T) {
+	s := newTestServer(t)
+	b := s.createBooking(nextWeek(10))
+
+	tests := []struct {
+		method string
+		path   string
+		body   any
+		status int
+		code   string
+	}{
+		{method: http.MethodGet, path: "/api/bookings/42", status: http.StatusNotFound, code: "NOT_FOUND"},
+		{method: http.MethodPost, path: "/api/bookings/42/cancel", body: gin.H{}, status: http.StatusNotFound, code: "NOT_FOUND"},
+		{method: http.MethodPost, path: "/api/bookings/" + b.ID + "/reschedule/42/accept", status: http.StatusNotFound, code: "NOT_FOUND"},
+		{method: http.MethodGet, path: "/api/bookings?projectId=42", status: http.StatusBadRequest, code: "VALIDATION_ERROR"},
+		{method: http.MethodGet, path: "/api/bookings?counterpartyId=42", status: http.StatusBadRequest, code: "VALIDATION_ERROR"},
+		{method: http.MethodPost, path: "/api/bookings", body: gin.H{"holdId": "42", "title": "Session"}, status: http.StatusBadRequest, code: "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		w := s.do(tt.method, tt.path, clientID, tt.body)
+		var env envelope
+		if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+		}
+		if w.Code != tt.status || env.Error == nil || env.Error.Code != tt.code {
+			t.Errorf("%s %s = %d %+v, want %d %s", tt.method, tt.path, w.Code, env.Error, tt.status, tt.code)
+		}
+	}
+}
+
+// The reason may be left out, but a body that is there has to be valid
+func TestReasonBody(t *testing.T) {
+	s := newTestServer(t)
+	booking := s.createBooking(nextWeek(10))
+
+	env := s.expect(s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/cancel", clientID, "plans changed"), http.StatusBadRequest)
+	if env.Error == nil || env.Error.Code != "VALIDATION_ERROR" {
+		t.Fatalf("malformed body error = %+v, want VALIDATION_ERROR", env.Error)
+	}
+
+	s.expect(s.do(http.MethodPost, "/api/bookings/"+booking.ID+"/cancel", clientID, nil), http.StatusOK, booking)
+	if booking.Status != models.BookingStatusCancelled {
+		t.Fatalf("booking cancelled without a body is %s", booking.Status)
+	}
+}
+
+func TestListBookingsPagesOnlyWhenAsked(t *testing.T) {
+	s := newTestServer(t)
+	for hour := 10; hour < 13; hour++ {
+		s.createBooking(nextWeek(hour))
+	}
+
+	var raw map[string]json.RawMessage
+	var all []*models.Booking
+	w := s.do(http.MethodGet, "/api/bookings?role=client", clientID, nil)
+	s.expect(w, http.StatusOK, &all)
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["nextCursor"]; ok || len(all) != 3 {
+		t.Fatalf("unpaged list = %d bookings with nextCursor %s, want all 3 and no cursor", len(all), raw["nextCursor"])
+	}
+
+	var page []*models.Booking
+	w = s.do(http.MethodGet, "/api/bookings?role=client&limit=2", clientID, nil)
+	s.expect(w, http.StatusOK, &page)
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	var next string
+	if err := json.Unmarshal(raw["nextCursor"], &next); err != nil || next == "" || len(page) != 2 {
+		t.Fatalf("first page = %d bookings with nextCursor %s, want 2 and a cursor", len(page), raw["nextCursor"])
+	}
+
+	w = s.do(http.MethodGet, "/api/bookings?role=client&limit=2&cursor="+next, clientID, nil)
+	s.expect(w, http.StatusOK, &page)
+	if len(page) != 1 || page[0].ID != all[2].ID {
+		t.Fatalf("second page = %d bookings, want the last one %s", len(page), all[2].ID)
+	}
+}
+
+func TestCalendarFeedURLUsesPublicBaseURL(t *testing.T) {
+	s := newTestServer(t)
+
+	var feed struct {
+		URL string `json:"url"`
+	}
+	w := s.do(http.MethodPost, "/api/bookings/calendar-feed", clientID, nil, "X-Forwarded-Proto", "gopher", "X-Forwarded-Host", "evil.example")
+	s.expect(w, http.StatusCreated, &feed)
+	if !strings.HasPrefix(feed.URL, publicBaseURL+"/api/calendar/") || !strings.HasSuffix(feed.URL, ".ics") {
+		t.Fatalf("feed URL = %s, want one on %s", feed.URL, publicBaseURL)
+	}
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *BookingHandler) CreateHold(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	var req models.CreateHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	hold, err := h.service.CreateHold(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": hold})
+}
+
+func (h *BookingHandler) ReleaseHold(c *gin.Context) {
+	userID := c.GetString("userId")
+	holdID := c.Param("holdId")
+
+	if err := h.service.ReleaseHold(c.Request.Context(), holdID, userID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Hold released"})
+}
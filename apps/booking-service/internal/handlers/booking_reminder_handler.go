@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *BookingHandler) GetBookingReminders(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+
+	reminders, err := h.service.GetBookingReminders(c.Request.Context(), bookingID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": reminders})
+}
+
+func (h *BookingHandler) GetReminderPreferences(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	prefs, err := h.service.GetReminderPreferences(c.Request.Context(), userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": prefs})
+}
+
+func (h *BookingHandler) UpdateReminderPreferences(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	var req models.ReminderPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	prefs, err := h.service.SetReminderPreferences(c.Request.Context(), userID, req.OffsetsMinutes)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": prefs})
+}
+
+// ResetReminderPreferences goes back to the default reminders
+func (h *BookingHandler) ResetReminderPreferences(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	prefs, err := h.service.SetReminderPreferences(c.Request.Context(), userID, nil)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": prefs})
+}
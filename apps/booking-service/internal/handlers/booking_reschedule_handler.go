@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *BookingHandler) ProposeReschedule(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+
+	var req models.ProposeRescheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	proposal, err := h.service.ProposeReschedule(c.Request.Context(), bookingID, userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": proposal})
+}
+
+func (h *BookingHandler) GetRescheduleRequests(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+
+	requests, err := h.service.GetRescheduleRequests(c.Request.Context(), bookingID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": requests})
+}
+
+func (h *BookingHandler) AcceptReschedule(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+	requestID := c.Param("requestId")
+
+	booking, err := h.service.AcceptReschedule(c.Request.Context(), bookingID, requestID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	respondBooking(c, http.StatusOK, booking)
+}
+
+func (h *BookingHandler) RejectReschedule(c *gin.Context) {
+	userID := c.GetString("userId")
+	bookingID := c.Param("id")
+	requestID := c.Param("requestId")
+
+	proposal, err := h.service.RejectReschedule(c.Request.Context(), bookingID, requestID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": proposal})
+}
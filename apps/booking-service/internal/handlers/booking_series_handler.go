@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *BookingHandler) CreateBookingSeries(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	var req models.CreateBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	series, err := h.service.CreateBookingSeries(c.Request.Context(), userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": series})
+}
+
+func (h *BookingHandler) GetBookingSeries(c *gin.Context) {
+	userID := c.GetString("userId")
+	seriesID := c.Param("seriesId")
+
+	series, err := h.service.GetBookingSeries(c.Request.Context(), seriesID, userID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": series})
+}
+
+func (h *BookingHandler) UpdateBookingSeries(c *gin.Context) {
+	userID := c.GetString("userId")
+	seriesID := c.Param("seriesId")
+
+	var req models.UpdateBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	series, err := h.service.UpdateBookingSeries(c.Request.Context(), seriesID, userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": series})
+}
+
+func (h *BookingHandler) CancelBookingSeries(c *gin.Context) {
+	userID := c.GetString("userId")
+	seriesID := c.Param("seriesId")
+
+	var req models.CancelBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	series, err := h.service.CancelBookingSeries(c.Request.Context(), seriesID, userID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": series})
+}
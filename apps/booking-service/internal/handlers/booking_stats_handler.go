@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetBookingStats reports on the caller's bookings over a period of calendar dates, by default
+// the last 30 days
+func (h *BookingHandler) GetBookingStats(c *gin.Context) {
+	userID := c.GetString("userId")
+
+	var query models.BookingStatsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.Error(apperr.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	stats, err := h.service.GetBookingStats(c.Request.Context(), userID, &query)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": stats})
+}
@@ -0,0 +1,108 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusTentative = "TENTATIVE"
+	StatusConfirmed = "CONFIRMED"
+	StatusCancelled = "CANCELLED"
+)
+
+// Event is a VEVENT. UID must stay the same across feed refreshes so calendar clients update
+// the existing entry instead of adding a new one.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Location     string
+	URL          string
+	Status       string
+	Start        time.Time
+	End          time.Time
+	Created      time.Time
+	LastModified time.Time
+}
+
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Encode renders the calendar as RFC 5545 text: CRLF line endings, escaped text values and
+// lines folded at 75 octets. Times are written in UTC.
+func (c *Calendar) Encode() []byte {
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		writeFolded(&buf, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Tulifo//Booking Service//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if c.Name != "" {
+		line("X-WR-CALNAME", escape(c.Name))
+	}
+	line("X-PUBLISHED-TTL", "PT1H")
+
+	for _, e := range c.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", e.UID)
+		line("DTSTAMP", formatTime(e.LastModified))
+		line("DTSTART", formatTime(e.Start))
+		line("DTEND", formatTime(e.End))
+		line("CREATED", formatTime(e.Created))
+		line("LAST-MODIFIED", formatTime(e.LastModified))
+		line("SUMMARY", escape(e.Summary))
+		if e.Description != "" {
+			line("DESCRIPTION", escape(e.Description))
+		}
+		if e.Location != "" {
+			line("LOCATION", escape(e.Location))
+		}
+		if e.URL != "" {
+			line("URL", e.URL)
+		}
+		if e.Status != "" {
+			line("STATUS", e.Status)
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return buf.Bytes()
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func escape(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeFolded splits content lines longer than 75 octets without breaking UTF-8 sequences
+func writeFolded(buf *bytes.Buffer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		buf.WriteString(s[:cut])
+		buf.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards the limit
+		limit = 74
+	}
+	buf.WriteString(s)
+	buf.WriteString("\r\n")
+}
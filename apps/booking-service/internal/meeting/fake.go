@@ -0,0 +1,62 @@
+package meeting
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"booking-service/internal/models"
+)
+
+// FakeProvider keeps its rooms in memory, for tests and local development
+type FakeProvider struct {
+	mu    sync.Mutex
+	err   error
+	next  int
+	rooms []string
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{}
+}
+
+func (p *FakeProvider) CreateRoom(ctx context.Context, b *models.Booking) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return "", p.err
+	}
+	p.next++
+	room := fmt.Sprintf("https://meet.example.test/%s-%d", b.ID, p.next)
+	p.rooms = append(p.rooms, room)
+	return room, nil
+}
+
+func (p *FakeProvider) RevokeRoom(ctx context.Context, url string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.rooms = slices.DeleteFunc(p.rooms, func(room string) bool { return room == url })
+	return nil
+}
+
+// Fail makes every later call fail with err, until it is called again with nil
+func (p *FakeProvider) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+}
+
+// Rooms returns the rooms open at the moment, oldest first
+func (p *FakeProvider) Rooms() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return slices.Clone(p.rooms)
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+
+	"booking-service/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Errors answers requests whose handler failed with c.Error. Domain errors are sent with the
+// status of their kind and their code; anything else is logged and reported as a bare 500, so
+// database and other internal errors never reach clients.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		renderError(c)
+	}
+}
+
+// renderError writes the last error attached to c, unless a response was already written
+func renderError(c *gin.Context) {
+	if len(c.Errors) == 0 || c.Writer.Written() {
+		return
+	}
+	err := c.Errors.Last().Err
+
+	var appErr *apperr.Error
+	if !errors.As(err, &appErr) || appErr.Kind == apperr.KindInternal {
+		log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+		c.JSON(apperr.KindInternal.Status(), gin.H{"success": false, "error": gin.H{"code": "INTERNAL_ERROR", "message": "Internal server error"}})
+		return
+	}
+
+	body := gin.H{"code": appErr.Code, "message": err.Error()}
+	if appErr.Details != nil {
+		body["details"] = appErr.Details
+	}
+	c.JSON(appErr.Kind.Status(), gin.H{"success": false, "error": body})
+}
@@ -0,0 +1,209 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/store"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// DefaultIdempotencyTTL is how long a key and its response are kept
+	DefaultIdempotencyTTL = 24 * time.Hour
+	// DefaultIdempotencyLease is how long a request may hold its key before a retry can take it
+	// over. It must outlast the slowest request, or a retry can run alongside the original.
+	DefaultIdempotencyLease = time.Minute
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodyBytes bounds the request bodies read into memory to fingerprint them
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+var (
+	ErrIdempotencyKeyTooLong  = apperr.BadRequest("VALIDATION_ERROR", "Idempotency-Key must be at most 255 characters")
+	ErrRequestBodyTooLarge    = apperr.BadRequest("VALIDATION_ERROR", "request body must be at most 1 MiB")
+	ErrUnreadableRequestBody  = apperr.BadRequest("VALIDATION_ERROR", "request body could not be read")
+	ErrIdempotencyKeyMismatch = apperr.Validation("IDEMPOTENCY_KEY_MISMATCH", "Idempotency-Key was already used for a different request")
+	ErrIdempotencyKeyInUse    = apperr.Conflict("IDEMPOTENCY_KEY_IN_USE", "A request with this Idempotency-Key is still being processed")
+)
+
+// replayedHeaders are the response headers stored with a response and sent again on replay
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// Idempotency replays the stored response when a client retries a request with the same
+// Idempotency-Key. Keys are scoped per user, so it must run after AuthMiddleware. Requests
+// without the header pass straight through.
+type Idempotency struct {
+	store store.IdempotencyStore
+	TTL   time.Duration
+	Lease time.Duration
+}
+
+func NewIdempotency(st store.IdempotencyStore) *Idempotency {
+	return &Idempotency{store: st, TTL: DefaultIdempotencyTTL, Lease: DefaultIdempotencyLease}
+}
+
+func (m *Idempotency) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.Error(ErrIdempotencyKeyTooLong)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				c.Error(ErrRequestBodyTooLarge)
+			} else {
+				c.Error(fmt.Errorf("%w: %v", ErrUnreadableRequestBody, err))
+			}
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		userID := c.GetString("userId")
+		requestFingerprint := fingerprint(c.Request.Method, c.Request.URL.Path, c.GetHeader("If-Match"), body)
+		now := time.Now()
+		leaseToken := uuid.New().String()
+		existing, err := m.store.Reserve(ctx, &store.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: requestFingerprint,
+			LeaseToken:  leaseToken,
+			LockedUntil: now.Add(m.Lease),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(m.TTL),
+		})
+		if err != nil {
+			// Errors logs it and answers with a bare 500
+			c.Error(fmt.Errorf("idempotency: reserving key: %w", err))
+			c.Abort()
+			return
+		}
+
+		if existing != nil {
+			replay(c, existing, requestFingerprint)
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// Server errors and panics release the key so the client can retry for real
+		completed := false
+		defer func() {
+			if !completed {
+				if err := m.store.Delete(context.WithoutCancel(ctx), userID, key, leaseToken); err != nil {
+					log.Printf("idempotency: releasing key: %v", err)
+				}
+			}
+		}()
+
+		c.Next()
+		// Errors are rendered here rather than on the way out so the response can be stored
+		renderError(c)
+
+		if status := writer.Status(); status < http.StatusInternalServerError {
+			headers := map[string]string{}
+			for _, name := range replayedHeaders {
+				if value := writer.Header().Get(name); value != "" {
+					headers[name] = value
+				}
+			}
+			// A request that outlived its lease loses the key to the retry that took it over
+			if err := m.store.Complete(context.WithoutCancel(ctx), userID, key, leaseToken, status, headers, writer.body.Bytes()); err != nil {
+				log.Printf("idempotency: storing response: %v", err)
+				return
+			}
+			completed = true
+		}
+	}
+}
+
+func replay(c *gin.Context, rec *store.IdempotencyRecord, requestFingerprint string) {
+	switch {
+	case rec.Fingerprint != requestFingerprint:
+		c.Error(ErrIdempotencyKeyMismatch)
+		c.Abort()
+	case rec.StatusCode == 0:
+		c.Error(ErrIdempotencyKeyInUse)
+		c.Abort()
+	default:
+		contentType := "application/json; charset=utf-8"
+		for name, value := range rec.Headers {
+			if name == "Content-Type" {
+				contentType = value
+				continue
+			}
+			c.Header(name, value)
+		}
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(rec.StatusCode, contentType, rec.Response)
+		c.Abort()
+	}
+}
+
+// RunSweeper deletes expired keys every interval until ctx is cancelled
+func (m *Idempotency) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.store.DeleteExpired(ctx, time.Now()); err != nil {
+			log.Printf("idempotency sweeper: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// fingerprint identifies a request by method, path, precondition and body, so a key reused for
+// anything else is caught
+func fingerprint(method, path, ifMatch string, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, method+" "+path+"\n"+ifMatch+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body as it is written
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"booking-service/internal/store"
+	"booking-service/internal/store/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testUserID = "22222222-2222-2222-2222-222222222222"
+
+func newIdempotentRouter(st store.IdempotencyStore) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	idempotency := NewIdempotency(st)
+
+	r := gin.New()
+	r.Use(Errors(), func(c *gin.Context) { c.Set("userId", testUserID) })
+	r.POST("/things", idempotency.Handler(), func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"success": true})
+	})
+	return r
+}
+
+func postThing(r *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var env struct {
+		Error *struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+	if env.Error == nil {
+		return ""
+	}
+	return env.Error.Code
+}
+
+func TestIdempotencyInFlightKeys(t *testing.T) {
+	const body = `{"name":"thing"}`
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		lockedUntil time.Time
+		status      int
+		code        string
+	}{
+		{name: "request still holds the lease", lockedUntil: now.Add(time.Minute), status: http.StatusConflict, code: "IDEMPOTENCY_KEY_IN_USE"},
+		// The request that reserved the key died, so the retry takes it over and runs
+		{name: "lease ran out", lockedUntil: now.Add(-time.Second), status: http.StatusCreated},
+	}
+	for _, tt := range tests {
+		st := memory.New().Idempotency()
+		_, err := st.Reserve(context.Background(), &store.IdempotencyRecord{
+			UserID:      testUserID,
+			Key:         "key-1",
+			Fingerprint: fingerprint(http.MethodPost, "/things", "", []byte(body)),
+			LeaseToken:  "crashed",
+			LockedUntil: tt.lockedUntil,
+			CreatedAt:   now.Add(-2 * time.Minute),
+			ExpiresAt:   now.Add(DefaultIdempotencyTTL),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := newIdempotentRouter(st)
+
+		w := postThing(r, "key-1", body)
+		if w.Code != tt.status || errorCode(t, w) != tt.code {
+			t.Errorf("%s: response = %d %s, want %d %s", tt.name, w.Code, errorCode(t, w), tt.status, tt.code)
+			continue
+		}
+		if tt.status == http.StatusCreated {
+			// The new owner's response is stored and replayed
+			retry := postThing(r, "key-1", body)
+			if retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "true" {
+				t.Errorf("%s: retry = %d, replayed %q, want the stored 201", tt.name, retry.Code, retry.Header().Get("Idempotent-Replayed"))
+			}
+		}
+	}
+}
+
+func TestIdempotencyLostLease(t *testing.T) {
+	st := memory.New().Idempotency()
+	ctx := context.Background()
+	now := time.Now()
+	reserve := func(token string, at time.Time) {
+		existing, err := st.Reserve(ctx, &store.IdempotencyRecord{
+			UserID: testUserID, Key: "key-1", Fingerprint: "f", LeaseToken: token,
+			LockedUntil: at.Add(time.Minute), CreatedAt: at, ExpiresAt: at.Add(DefaultIdempotencyTTL),
+		})
+		if err != nil || existing != nil {
+			t.Fatalf("Reserve(%s) = %+v, %v, want the reservation", token, existing, err)
+		}
+	}
+	reserve("slow", now)
+	reserve("retry", now.Add(2*time.Minute))
+
+	// The request that lost its lease can neither store its response nor release the key
+	if err := st.Complete(ctx, testUserID, "key-1", "slow", http.StatusCreated, nil, []byte("slow")); err != store.ErrNotFound {
+		t.Fatalf("Complete with a lost lease error = %v, want ErrNotFound", err)
+	}
+	if err := st.Delete(ctx, testUserID, "key-1", "slow"); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Complete(ctx, testUserID, "key-1", "retry", http.StatusCreated, nil, []byte("retry")); err != nil {
+		t.Fatalf("Complete by the lease holder: %v", err)
+	}
+}
+
+func TestIdempotencyRejectsBadRequests(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		body string
+		code string
+	}{
+		{name: "key too long", key: strings.Repeat("k", maxIdempotencyKeyLength+1), body: "{}", code: "VALIDATION_ERROR"},
+		{name: "body too large", key: "key-1", body: `"` + strings.Repeat("x", maxIdempotentBodyBytes) + `"`, code: "VALIDATION_ERROR"},
+	}
+	for _, tt := range tests {
+		st := memory.New().Idempotency()
+		w := postThing(newIdempotentRouter(st), tt.key, tt.body)
+		if w.Code != http.StatusBadRequest || errorCode(t, w) != tt.code {
+			t.Errorf("%s: response = %d %s, want 400 %s", tt.name, w.Code, errorCode(t, w), tt.code)
+		}
+		if existing, _ := st.Reserve(context.Background(), &store.IdempotencyRecord{UserID: testUserID, Key: tt.key, CreatedAt: time.Now()}); existing != nil {
+			t.Errorf("%s: the rejected request reserved its key", tt.name)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"booking-service/internal/apperr"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var ErrNotFound = apperr.NotFound("NOT_FOUND", "not found")
+
+// UUIDParams answers 404 when one of the named path parameters is not a UUID. Every record is
+// keyed by a UUID, so such a request cannot match anything, and Postgres would reject the
+// malformed ID instead of finding no row.
+func UUIDParams(names ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, name := range names {
+			if value, ok := c.Params.Get(name); ok {
+				if _, err := uuid.Parse(value); err != nil {
+					c.Error(ErrNotFound)
+					c.Abort()
+					return
+				}
+			}
+		}
+		c.Next()
+	}
+}
@@ -1,6 +1,11 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"booking-service/internal/money"
+)
 
 type UserInfo struct {
 	ID        string  `json:"id"`
@@ -10,41 +15,111 @@ type UserInfo struct {
 }
 
 type Booking struct {
-	ID          string    `json:"id"`
-	WorkerID    string    `json:"workerId"`
-	ClientID    string    `json:"clientId"`
-	ProjectID   *string   `json:"projectId,omitempty"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	StartTime   time.Time `json:"startTime"`
-	EndTime     time.Time `json:"endTime"`
-	Duration    int       `json:"duration"`
-	HourlyRate  float64   `json:"hourlyRate"`
-	TotalAmount float64   `json:"totalAmount"`
-	Currency    string    `json:"currency"`
-	Status      string    `json:"status"`
-	MeetingURL  *string   `json:"meetingUrl,omitempty"`
-	Notes       *string   `json:"notes,omitempty"`
-	CreatedAt   time.Time `json:"createdAt"`
-	UpdatedAt   time.Time `json:"updatedAt"`
-	Worker      *UserInfo `json:"worker,omitempty"`
-	Client      *UserInfo `json:"client,omitempty"`
+	ID              string       `json:"id"`
+	WorkerID        string       `json:"workerId"`
+	ClientID        string       `json:"clientId"`
+	ProjectID       *string      `json:"projectId,omitempty"`
+	SeriesID        *string      `json:"seriesId,omitempty"`
+	SeriesException bool         `json:"seriesException,omitempty"`
+	Title           string       `json:"title"`
+	Description     string       `json:"description"`
+	StartTime       time.Time    `json:"startTime"`
+	EndTime         time.Time    `json:"endTime"`
+	Duration        int          `json:"duration"`
+	HourlyRate      money.Amount `json:"hourlyRate"`
+	TotalAmount     money.Amount `json:"totalAmount"`
+	Currency        string       `json:"currency"`
+	Status          string       `json:"status"`
+	IsRemote        bool         `json:"isRemote"`
+	MeetingURL      *string      `json:"meetingUrl,omitempty"`
+	Notes           *string      `json:"notes,omitempty"`
+	CreatedAt       time.Time    `json:"createdAt"`
+	UpdatedAt       time.Time    `json:"updatedAt"`
+	// Version goes up by one on every write and is served as the booking's ETag
+	Version int       `json:"version"`
+	Worker  *UserInfo `json:"worker,omitempty"`
+	Client  *UserInfo `json:"client,omitempty"`
+
+	PriceBreakdown     *PriceBreakdown      `json:"priceBreakdown,omitempty"`
+	CancellationPolicy *CancellationPolicy  `json:"cancellationPolicy,omitempty"`
+	Cancellation       *CancellationOutcome `json:"cancellation,omitempty"`
 }
 
+// CreateBookingRequest books a slot. With HoldID the worker and times come from the client's hold;
+// if they are sent as well they must match it.
 type CreateBookingRequest struct {
-	WorkerID    string    `json:"workerId" binding:"required"`
-	ProjectID   *string   `json:"projectId"`
+	HoldID      *string   `json:"holdId" binding:"omitempty,uuid"`
+	WorkerID    string    `json:"workerId" binding:"required_without=HoldID,omitempty,uuid"`
+	ProjectID   *string   `json:"projectId" binding:"omitempty,uuid"`
 	Title       string    `json:"title" binding:"required"`
 	Description string    `json:"description"`
-	StartTime   time.Time `json:"startTime" binding:"required"`
-	EndTime     time.Time `json:"endTime" binding:"required"`
-	HourlyRate  float64   `json:"hourlyRate" binding:"required"`
+	StartTime   time.Time `json:"startTime" binding:"required_without=HoldID"`
+	EndTime     time.Time `json:"endTime" binding:"required_without=HoldID"`
+	IsRemote    bool      `json:"isRemote"`
 	Notes       *string   `json:"notes"`
 }
 
+// SlotHold reserves a range on a worker's calendar for a client until ExpiresAt
+type SlotHold struct {
+	ID        string    `json:"id"`
+	WorkerID  string    `json:"workerId"`
+	ClientID  string    `json:"clientId"`
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type CreateHoldRequest struct {
+	WorkerID  string    `json:"workerId" binding:"required,uuid"`
+	StartTime time.Time `json:"startTime" binding:"required"`
+	EndTime   time.Time `json:"endTime" binding:"required"`
+}
+
+// ListBookingsQuery holds the query parameters of GET /api/bookings. Status may repeat or be
+// comma separated; a leading "-" on Sort means descending.
+type ListBookingsQuery struct {
+	Role           string    `form:"role" binding:"omitempty,oneof=worker client"`
+	Status         []string  `form:"status"`
+	From           time.Time `form:"from"`
+	To             time.Time `form:"to"`
+	CounterpartyID string    `form:"counterpartyId" binding:"omitempty,uuid"`
+	ProjectID      string    `form:"projectId" binding:"omitempty,uuid"`
+	When           string    `form:"when" binding:"omitempty,oneof=upcoming past"`
+	Sort           string    `form:"sort" binding:"omitempty,oneof=startTime -startTime createdAt -createdAt"`
+	Limit          int       `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor         string    `form:"cursor"`
+}
+
+// AdminBookingsQuery holds the query parameters of GET /api/admin/bookings, which searches
+// every booking
+type AdminBookingsQuery struct {
+	WorkerID  string    `form:"workerId" binding:"omitempty,uuid"`
+	ClientID  string    `form:"clientId" binding:"omitempty,uuid"`
+	Status    []string  `form:"status"`
+	From      time.Time `form:"from"`
+	To        time.Time `form:"to"`
+	ProjectID string    `form:"projectId" binding:"omitempty,uuid"`
+	Sort      string    `form:"sort" binding:"omitempty,oneof=startTime -startTime createdAt -createdAt"`
+	Limit     int       `form:"limit" binding:"omitempty,min=1,max=100"`
+	Cursor    string    `form:"cursor"`
+}
+
+type AdminCancelRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// AdminStatusOverrideRequest sets a booking's status regardless of the state machine; Note is
+// the audit note recorded in its history
+type AdminStatusOverrideRequest struct {
+	Status string `json:"status" binding:"required"`
+	Note   string `json:"note" binding:"required"`
+}
+
 type UpdateBookingRequest struct {
 	Title       *string `json:"title"`
 	Description *string `json:"description"`
+	IsRemote    *bool   `json:"isRemote"`
 	Notes       *string `json:"notes"`
 }
 
@@ -61,9 +136,336 @@ type TimeSlot struct {
 	IsAvailable bool      `json:"isAvailable"`
 }
 
+type DaySlots struct {
+	Date  string     `json:"date"`
+	Slots []TimeSlot `json:"slots"`
+}
+
+// WorkerCalendar is what support staff see of a worker's calendar settings
+type WorkerCalendar struct {
+	WorkerID        string             `json:"workerId"`
+	Timezone        string             `json:"timezone"`
+	Availability    []AvailabilitySlot `json:"availability"`
+	SchedulingRules SchedulingRules    `json:"schedulingRules"`
+	BlockedSlots    []*BlockedSlot     `json:"blockedSlots"`
+}
+
 type BlockedSlot struct {
 	ID        string    `json:"id"`
 	StartTime time.Time `json:"startTime" binding:"required"`
 	EndTime   time.Time `json:"endTime" binding:"required"`
 	Reason    string    `json:"reason"`
 }
+
+const (
+	BookingStatusPending    = "pending"
+	BookingStatusConfirmed  = "confirmed"
+	BookingStatusInProgress = "in_progress"
+	BookingStatusCompleted  = "completed"
+	BookingStatusCancelled  = "cancelled"
+	BookingStatusDeclined   = "declined"
+	BookingStatusNoShow     = "no_show"
+	// BookingStatusExpired is a pending booking the worker never responded to
+	BookingStatusExpired = "expired"
+	// BookingStatusAwaitingCompletion is a session past its end time that nobody has completed yet
+	BookingStatusAwaitingCompletion = "awaiting_completion"
+)
+
+type SchedulingRules struct {
+	BufferBeforeMinutes int `json:"bufferBeforeMinutes" binding:"gte=0,lte=240"`
+	BufferAfterMinutes  int `json:"bufferAfterMinutes" binding:"gte=0,lte=240"`
+	MinNoticeMinutes    int `json:"minNoticeMinutes" binding:"gte=0"`
+	MaxAdvanceDays      int `json:"maxAdvanceDays" binding:"gte=0"`
+	SlotStepMinutes     int `json:"slotStepMinutes" binding:"gte=5,lte=240"`
+	MinDurationMinutes  int `json:"minDurationMinutes" binding:"gte=5"`
+	MaxDurationMinutes  int `json:"maxDurationMinutes" binding:"gtefield=MinDurationMinutes"`
+}
+
+const (
+	SeriesStatusActive    = "active"
+	SeriesStatusCancelled = "cancelled"
+
+	SeriesScopeThis      = "this"
+	SeriesScopeFollowing = "following"
+	SeriesScopeAll       = "all"
+)
+
+type BookingSeries struct {
+	ID             string       `json:"id"`
+	WorkerID       string       `json:"workerId"`
+	ClientID       string       `json:"clientId"`
+	ProjectID      *string      `json:"projectId,omitempty"`
+	ParentSeriesID *string      `json:"parentSeriesId,omitempty"`
+	Title          string       `json:"title"`
+	Description    string       `json:"description"`
+	RRule          string       `json:"rrule"`
+	StartTime      time.Time    `json:"startTime"`
+	Duration       int          `json:"duration"`
+	HourlyRate     money.Amount `json:"hourlyRate"`
+	Currency       string       `json:"currency"`
+	Timezone       string       `json:"timezone"`
+	ExceptionDates []string     `json:"exceptionDates"`
+	Status         string       `json:"status"`
+	Notes          *string      `json:"notes,omitempty"`
+	CreatedAt      time.Time    `json:"createdAt"`
+	UpdatedAt      time.Time    `json:"updatedAt"`
+	Occurrences    []*Booking   `json:"occurrences,omitempty"`
+	// Skipped lists the occurrences a scoped edit or cancellation left unchanged
+	Skipped []SkippedOccurrence `json:"skipped,omitempty"`
+}
+
+type CreateBookingSeriesRequest struct {
+	WorkerID       string    `json:"workerId" binding:"required,uuid"`
+	ProjectID      *string   `json:"projectId" binding:"omitempty,uuid"`
+	Title          string    `json:"title" binding:"required"`
+	Description    string    `json:"description"`
+	StartTime      time.Time `json:"startTime" binding:"required"`
+	EndTime        time.Time `json:"endTime" binding:"required"`
+	RRule          string    `json:"rrule" binding:"required"`
+	ExceptionDates []string  `json:"exceptionDates"`
+	SkipConflicts  bool      `json:"skipConflicts"`
+	IsRemote       bool      `json:"isRemote"`
+	Notes          *string   `json:"notes"`
+}
+
+type UpdateBookingSeriesRequest struct {
+	Scope       string  `json:"scope" binding:"required,oneof=this following all"`
+	BookingID   string  `json:"bookingId" binding:"omitempty,uuid"`
+	Title       *string `json:"title"`
+	Description *string `json:"description"`
+	Notes       *string `json:"notes"`
+}
+
+type CancelBookingSeriesRequest struct {
+	Scope     string `json:"scope" binding:"required,oneof=this following all"`
+	BookingID string `json:"bookingId" binding:"omitempty,uuid"`
+	Reason    string `json:"reason"`
+}
+
+// SkippedOccurrence is an occurrence whose status no longer allows the change made to its series
+type SkippedOccurrence struct {
+	BookingID string    `json:"bookingId"`
+	StartTime time.Time `json:"startTime"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason"`
+}
+
+type SeriesConflict struct {
+	StartTime time.Time `json:"startTime"`
+	EndTime   time.Time `json:"endTime"`
+	Reason    string    `json:"reason"`
+}
+
+const (
+	RescheduleStatusPending    = "pending"
+	RescheduleStatusAccepted   = "accepted"
+	RescheduleStatusRejected   = "rejected"
+	RescheduleStatusWithdrawn  = "withdrawn"
+	RescheduleStatusSuperseded = "superseded"
+)
+
+type RescheduleRequest struct {
+	ID          string     `json:"id"`
+	BookingID   string     `json:"bookingId"`
+	ProposedBy  string     `json:"proposedBy"`
+	StartTime   time.Time  `json:"startTime"`
+	EndTime     time.Time  `json:"endTime"`
+	Reason      *string    `json:"reason,omitempty"`
+	Status      string     `json:"status"`
+	RespondedBy *string    `json:"respondedBy,omitempty"`
+	RespondedAt *time.Time `json:"respondedAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+type ProposeRescheduleRequest struct {
+	StartTime time.Time `json:"startTime" binding:"required"`
+	EndTime   time.Time `json:"endTime" binding:"required"`
+	Reason    *string   `json:"reason"`
+}
+
+const (
+	ActorRoleWorker = "worker"
+	ActorRoleClient = "client"
+	// ActorRoleStaff is support staff acting on a booking they are not a party to
+	ActorRoleStaff = "staff"
+	// ActorRoleSystem is used for changes the service makes on its own, with no actor id
+	ActorRoleSystem = "system"
+)
+
+// BookingEvent is an entry in a booking's history: who did what to it, and what changed
+type BookingEvent struct {
+	ID         string                 `json:"id"`
+	BookingID  string                 `json:"bookingId"`
+	ActorID    *string                `json:"actorId,omitempty"`
+	ActorRole  string                 `json:"actorRole"`
+	Action     string                 `json:"action"`
+	FromStatus *string                `json:"fromStatus,omitempty"`
+	ToStatus   string                 `json:"toStatus"`
+	Changes    map[string]FieldChange `json:"changes,omitempty"`
+	Reason     *string                `json:"reason,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+// DeadLetter is an outbox event the relay gave up publishing after repeated failures
+type DeadLetter struct {
+	ID             string          `json:"id"`
+	AggregateType  string          `json:"aggregateType"`
+	AggregateID    string          `json:"aggregateId"`
+	EventType      string          `json:"eventType"`
+	Payload        json.RawMessage `json:"payload"`
+	Attempts       int             `json:"attempts"`
+	LastError      *string         `json:"lastError,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeadLetteredAt time.Time       `json:"deadLetteredAt"`
+}
+
+// BookingReminder is a reminder due to one party of a booking, OffsetMinutes before it starts
+type BookingReminder struct {
+	ID            string     `json:"id"`
+	BookingID     string     `json:"bookingId"`
+	UserID        string     `json:"userId"`
+	OffsetMinutes int        `json:"offsetMinutes"`
+	DueAt         time.Time  `json:"dueAt"`
+	SentAt        *time.Time `json:"sentAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+// ReminderPreferences are the minutes before a booking's start a user is reminded at; an empty
+// list turns reminders off
+type ReminderPreferences struct {
+	OffsetsMinutes []int `json:"offsetsMinutes" binding:"required,max=5,dive,min=1,max=20160"`
+	IsDefault      bool  `json:"isDefault"`
+}
+
+// ReminderNotification is the payload of booking.reminder events, in the shape the notification
+// service sends
+type ReminderNotification struct {
+	UserID   string         `json:"userId"`
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Body     string         `json:"body"`
+	Data     map[string]any `json:"data"`
+	Channels []string       `json:"channels"`
+}
+
+// FieldChange is the old and new value of a changed booking field, keyed by its JSON name
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+const (
+	CancellationPolicyFlexible = "flexible"
+	CancellationPolicyModerate = "moderate"
+	CancellationPolicyStrict   = "strict"
+	CancellationPolicyCustom   = "custom"
+)
+
+// CancellationTier refunds RefundPercent of the total to a client who cancels at least
+// HoursBeforeStart hours ahead
+type CancellationTier struct {
+	HoursBeforeStart int `json:"hoursBeforeStart" binding:"gte=0"`
+	RefundPercent    int `json:"refundPercent" binding:"gte=0,lte=100"`
+}
+
+type CancellationPolicy struct {
+	Name                 string             `json:"name" binding:"required,oneof=flexible moderate strict custom"`
+	Tiers                []CancellationTier `json:"tiers" binding:"dive"`
+	WorkerPenaltyPercent int                `json:"workerPenaltyPercent" binding:"gte=0,lte=100"`
+}
+
+type CancellationOutcome struct {
+	Policy           string       `json:"policy"`
+	CancelledBy      string       `json:"cancelledBy"`
+	CancelledAt      time.Time    `json:"cancelledAt"`
+	HoursBeforeStart float64      `json:"hoursBeforeStart"`
+	RefundPercent    int          `json:"refundPercent"`
+	ClientRefund     money.Amount `json:"clientRefund"`
+	ClientPenalty    money.Amount `json:"clientPenalty"`
+	WorkerPenalty    money.Amount `json:"workerPenalty"`
+	Currency         string       `json:"currency"`
+}
+
+// RateCard is how a worker prices their time. HourlyRate, when set, overrides the rate on their
+// profile. Surcharges are percentages of the hourly rate added for the minutes they cover;
+// weekend minutes never also count as after-hours. WorkdayStart and WorkdayEnd are "HH:MM" in
+// the worker's timezone.
+type RateCard struct {
+	HourlyRate                 *money.Amount `json:"hourlyRate,omitempty"`
+	WeekendSurchargePercent    int           `json:"weekendSurchargePercent" binding:"gte=0,lte=500"`
+	AfterHoursSurchargePercent int           `json:"afterHoursSurchargePercent" binding:"gte=0,lte=500"`
+	WorkdayStart               string        `json:"workdayStart"`
+	WorkdayEnd                 string        `json:"workdayEnd"`
+	RushNoticeHours            int           `json:"rushNoticeHours" binding:"gte=0"`
+	RushFee                    money.Amount  `json:"rushFee"`
+}
+
+const (
+	PriceLineBase       = "base"
+	PriceLineWeekend    = "weekend"
+	PriceLineAfterHours = "after_hours"
+	PriceLineRush       = "rush"
+)
+
+type PriceLine struct {
+	Type    string       `json:"type"`
+	Minutes int          `json:"minutes,omitempty"`
+	Percent int          `json:"percent,omitempty"`
+	Amount  money.Amount `json:"amount"`
+}
+
+// PriceBreakdown is a quote for a slot; bookings keep the one they were priced with
+type PriceBreakdown struct {
+	Currency   string       `json:"currency"`
+	HourlyRate money.Amount `json:"hourlyRate"`
+	Lines      []PriceLine  `json:"lines"`
+	Total      money.Amount `json:"total"`
+	QuotedAt   time.Time    `json:"quotedAt"`
+}
+
+type QuoteRequest struct {
+	WorkerID  string    `form:"workerId" binding:"required,uuid"`
+	StartTime time.Time `form:"startTime" binding:"required"`
+	EndTime   time.Time `form:"endTime" binding:"required"`
+}
+
+// BookingStatsQuery holds the query parameters of GET /api/bookings/stats. From and To are
+// calendar dates, both included, in Timezone or else the worker's own timezone.
+type BookingStatsQuery struct {
+	Role     string    `form:"role" binding:"omitempty,oneof=worker client"`
+	From     time.Time `form:"from" time_format:"2006-01-02"`
+	To       time.Time `form:"to" time_format:"2006-01-02"`
+	GroupBy  string    `form:"groupBy" binding:"omitempty,oneof=day week month"`
+	Timezone string    `form:"tz"`
+}
+
+// BookingStats sums up the bookings starting in a period. Rates and averages are null when there
+// is nothing to base them on, and availability is only reported to workers.
+type BookingStats struct {
+	Bookings             int                     `json:"bookings"`
+	ByStatus             map[string]int          `json:"byStatus"`
+	BookedHours          float64                 `json:"bookedHours"`
+	AvailableHours       *float64                `json:"availableHours,omitempty"`
+	Utilisation          *float64                `json:"utilisation,omitempty"`
+	GrossAmount          map[string]money.Amount `json:"grossAmount"`
+	CancellationRate     *float64                `json:"cancellationRate"`
+	NoShowRate           *float64                `json:"noShowRate"`
+	AverageLeadTimeHours *float64                `json:"averageLeadTimeHours"`
+}
+
+// BookingStatsGroup is the stats of the days From to To, both included
+type BookingStatsGroup struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	BookingStats
+}
+
+type BookingStatsReport struct {
+	Role     string               `json:"role"`
+	From     string               `json:"from"`
+	To       string               `json:"to"`
+	Timezone string               `json:"timezone"`
+	GroupBy  string               `json:"groupBy"`
+	Totals   BookingStats         `json:"totals"`
+	Groups   []*BookingStatsGroup `json:"groups"`
+}
@@ -0,0 +1,55 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// DefaultCurrency is used for workers who haven't picked a currency
+const DefaultCurrency = "USD"
+
+// minorUnits maps active ISO 4217 codes that don't use two decimal places to their exponent
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// twoDecimalCurrencies lists the remaining active ISO 4217 codes
+var twoDecimalCurrencies = strings.Fields(`
+	AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD BTN BWP BYN
+	BZD CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP
+	GEL GHS GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP
+	LKR LRD LSL MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR
+	NZD PAB PEN PGK PHP PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN
+	SVC SYP SZL THB TJS TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VES VED WST XCD YER ZAR ZMW
+	ZWG
+`)
+
+func init() {
+	for _, code := range twoDecimalCurrencies {
+		minorUnits[code] = 2
+	}
+}
+
+// MinorUnits returns the number of decimal places ISO 4217 defines for currency
+func MinorUnits(currency string) (int, error) {
+	digits, ok := minorUnits[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownCurrency, currency)
+	}
+	return digits, nil
+}
+
+// NormalizeCurrency upper-cases code and checks it is a known ISO 4217 currency
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if _, err := MinorUnits(code); err != nil {
+		return "", err
+	}
+	return code, nil
+}
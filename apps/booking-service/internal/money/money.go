@@ -0,0 +1,237 @@
+// Package money holds amounts as exact fixed-point decimals. Amounts never pass through float64,
+// and Round brings them to the minor unit of their ISO 4217 currency.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// scale is the number of fractional digits an Amount keeps, enough for every ISO 4217 minor unit
+const scale = 4
+
+var (
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrOverflow means a result does not fit in an Amount
+	ErrOverflow = errors.New("amount out of range")
+)
+
+var pow10 = [...]int64{1, 10, 100, 1000, 10000}
+
+// Amount is a decimal with four fractional digits. The zero value is 0.
+type Amount struct {
+	units int64 // value * 10^scale
+}
+
+// FromMinor returns minor units of currency (cents for USD, yen for JPY) as an Amount
+func FromMinor(minor int64, currency string) (Amount, error) {
+	digits, err := MinorUnits(currency)
+	if err != nil {
+		return Amount{}, err
+	}
+	units, err := scaleUp(minor, pow10[scale-digits])
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{units: units}, nil
+}
+
+// Parse reads a plain decimal such as "12", "-0.5" or "1234.5678"
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	negative := strings.HasPrefix(s, "-")
+	if negative || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || len(frac) > scale || strings.ContainsAny(whole+frac, "+-") {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	frac += strings.Repeat("0", scale-len(frac))
+
+	units, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Amount{}, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if negative {
+		units = -units
+	}
+	return Amount{units: units}, nil
+}
+
+// MustParse is Parse for constants; it panics on malformed input
+func MustParse(s string) Amount {
+	a, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func (a Amount) IsZero() bool     { return a.units == 0 }
+func (a Amount) IsNegative() bool { return a.units < 0 }
+
+func (a Amount) Add(b Amount) Amount { return Amount{units: a.units + b.units} }
+func (a Amount) Sub(b Amount) Amount { return Amount{units: a.units - b.units} }
+
+func (a Amount) Cmp(b Amount) int {
+	switch {
+	case a.units < b.units:
+		return -1
+	case a.units > b.units:
+		return 1
+	}
+	return 0
+}
+
+// MulRat returns a * num / den, rounded half away from zero to the Amount's four digits. It fails
+// with ErrOverflow when the result does not fit in an Amount.
+func (a Amount) MulRat(num, den int64) (Amount, error) {
+	if den == 0 {
+		return Amount{}, fmt.Errorf("%w: division by zero", ErrInvalidAmount)
+	}
+	n := new(big.Int).Mul(big.NewInt(a.units), big.NewInt(num))
+	units, err := divRound(n, big.NewInt(den))
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{units: units}, nil
+}
+
+// Percent returns pct percent of a, e.g. a.Percent(15) for 15%
+func (a Amount) Percent(pct int) (Amount, error) {
+	return a.MulRat(int64(pct), 100)
+}
+
+// Round rounds half away from zero to the minor unit of currency
+func (a Amount) Round(currency string) (Amount, error) {
+	digits, err := MinorUnits(currency)
+	if err != nil {
+		return Amount{}, err
+	}
+	step := pow10[scale-digits]
+	steps, err := divRound(big.NewInt(a.units), big.NewInt(step))
+	if err != nil {
+		return Amount{}, err
+	}
+	units, err := scaleUp(steps, step)
+	if err != nil {
+		return Amount{}, err
+	}
+	return Amount{units: units}, nil
+}
+
+// Minor returns a in minor units of currency, rounding first
+func (a Amount) Minor(currency string) (int64, error) {
+	rounded, err := a.Round(currency)
+	if err != nil {
+		return 0, err
+	}
+	digits, _ := MinorUnits(currency)
+	return rounded.units / pow10[scale-digits], nil
+}
+
+// divRound divides n by d, rounding half away from zero, and fails if the quotient overflows
+func divRound(n, d *big.Int) (int64, error) {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	// |2r| >= |d| means the remainder is at least half way, so round away from zero
+	if r.Sign() != 0 && new(big.Int).Abs(new(big.Int).Lsh(r, 1)).Cmp(new(big.Int).Abs(d)) >= 0 {
+		if (r.Sign() < 0) != (d.Sign() < 0) {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	if !q.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return q.Int64(), nil
+}
+
+// scaleUp returns v * factor, failing rather than wrapping around when it overflows
+func scaleUp(v, factor int64) (int64, error) {
+	product := new(big.Int).Mul(big.NewInt(v), big.NewInt(factor))
+	if !product.IsInt64() {
+		return 0, ErrOverflow
+	}
+	return product.Int64(), nil
+}
+
+// String formats a without trailing fractional zeros, e.g. "52.5" or "3"
+func (a Amount) String() string {
+	units := a.units
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	whole := units / pow10[scale]
+	frac := strings.TrimRight(fmt.Sprintf("%0*d", scale, units%pow10[scale]), "0")
+	if frac == "" {
+		return sign + strconv.FormatInt(whole, 10)
+	}
+	return sign + strconv.FormatInt(whole, 10) + "." + frac
+}
+
+// MarshalJSON writes a JSON number holding the exact decimal
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a decimal string
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Scan reads a NUMERIC column, which drivers hand over as text
+func (a *Amount) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = Amount{}
+		return nil
+	case string:
+		parsed, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	case []byte:
+		return a.Scan(string(v))
+	case int64:
+		units, err := scaleUp(v, pow10[scale])
+		if err != nil {
+			return err
+		}
+		*a = Amount{units: units}
+		return nil
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrInvalidAmount, src)
+}
+
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
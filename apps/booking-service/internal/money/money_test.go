@@ -0,0 +1,197 @@
+package money_test
+
+import (
+	"errors"
+	"testing"
+
+	"booking-service/internal/money"
+)
+
+// maxAmount is the largest Amount there is
+const maxAmount = "922337203685477.5807"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  bool
+	}{
+		{in: "12", want: "12"},
+		{in: "-0.5", want: "-0.5"},
+		{in: "+3.10", want: "3.1"},
+		{in: ".25", want: "0.25"},
+		{in: " 1234.5678 ", want: "1234.5678"},
+		{in: maxAmount, want: maxAmount},
+		{in: "1.23456", err: true},
+		{in: "", err: true},
+		{in: ".", err: true},
+		{in: "--1", err: true},
+		{in: "1e3", err: true},
+		{in: "922337203685478", err: true},
+	}
+	for _, tt := range tests {
+		got, err := money.Parse(tt.in)
+		if tt.err {
+			if !errors.Is(err, money.ErrInvalidAmount) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidAmount", tt.in, err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("Parse(%q) = %s, %v, want %s", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     string
+		err      error
+	}{
+		{amount: "10.005", currency: "USD", want: "10.01"},
+		{amount: "10.0049", currency: "USD", want: "10"},
+		{amount: "-10.005", currency: "USD", want: "-10.01"},
+		{amount: "99.5", currency: "JPY", want: "100"},
+		{amount: "-0.4", currency: "JPY", want: "0"},
+		{amount: "1.2345", currency: "KWD", want: "1.235"},
+		{amount: "1.2345", currency: "CLF", want: "1.2345"},
+		{amount: "1", currency: "XXX", err: money.ErrUnknownCurrency},
+		// Rounding up past the largest Amount
+		{amount: maxAmount, currency: "JPY", err: money.ErrOverflow},
+	}
+	for _, tt := range tests {
+		got, err := money.MustParse(tt.amount).Round(tt.currency)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s.Round(%s) error = %v, want %v", tt.amount, tt.currency, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("%s.Round(%s) = %s, %v, want %s", tt.amount, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestMulRat(t *testing.T) {
+	tests := []struct {
+		amount   string
+		num, den int64
+		want     string
+		err      error
+	}{
+		{amount: "40", num: 90, den: 60, want: "60"},
+		{amount: "10", num: 1, den: 3, want: "3.3333"},
+		{amount: "20", num: 1, den: 3, want: "6.6667"},
+		{amount: "-20", num: 1, den: 3, want: "-6.6667"},
+		{amount: "0.0001", num: 1, den: 2, want: "0.0001"},
+		{amount: "0.0001", num: -1, den: 2, want: "-0.0001"},
+		{amount: maxAmount, num: 1, den: 1, want: maxAmount},
+		// Products beyond int64 are fine as long as the quotient fits
+		{amount: maxAmount, num: 1000, den: 1000, want: maxAmount},
+		{amount: maxAmount, num: 2, den: 1, err: money.ErrOverflow},
+		{amount: "-" + maxAmount, num: 3, den: 2, err: money.ErrOverflow},
+		{amount: "1", num: 1, den: 0, err: money.ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		got, err := money.MustParse(tt.amount).MulRat(tt.num, tt.den)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s.MulRat(%d, %d) error = %v, want %v", tt.amount, tt.num, tt.den, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("%s.MulRat(%d, %d) = %s, %v, want %s", tt.amount, tt.num, tt.den, got, err, tt.want)
+		}
+	}
+}
+
+func TestFromMinorAndMinor(t *testing.T) {
+	tests := []struct {
+		minor    int64
+		currency string
+		want     string
+		err      error
+	}{
+		{minor: 1050, currency: "USD", want: "10.5"},
+		{minor: 1050, currency: "JPY", want: "1050"},
+		{minor: -1, currency: "KWD", want: "-0.001"},
+		{minor: 1 << 62, currency: "JPY", err: money.ErrOverflow},
+		{minor: 1, currency: "usd", err: money.ErrUnknownCurrency},
+	}
+	for _, tt := range tests {
+		got, err := money.FromMinor(tt.minor, tt.currency)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("FromMinor(%d, %s) error = %v, want %v", tt.minor, tt.currency, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("FromMinor(%d, %s) = %s, %v, want %s", tt.minor, tt.currency, got, err, tt.want)
+			continue
+		}
+		if minor, err := got.Minor(tt.currency); err != nil || minor != tt.minor {
+			t.Errorf("%s.Minor(%s) = %d, %v, want %d", got, tt.currency, minor, err, tt.minor)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: `52.5`, want: `52.5`},
+		{in: `"52.50"`, want: `52.5`},
+		{in: `-3`, want: `-3`},
+		{in: `null`, want: `0`},
+	}
+	for _, tt := range tests {
+		var a money.Amount
+		if err := a.UnmarshalJSON([]byte(tt.in)); err != nil {
+			t.Errorf("UnmarshalJSON(%s): %v", tt.in, err)
+			continue
+		}
+		out, _ := a.MarshalJSON()
+		if string(out) != tt.want {
+			t.Errorf("UnmarshalJSON(%s) then MarshalJSON = %s, want %s", tt.in, out, tt.want)
+		}
+	}
+
+	var a money.Amount
+	if err := a.UnmarshalJSON([]byte(`1.5e3`)); !errors.Is(err, money.ErrInvalidAmount) {
+		t.Errorf("UnmarshalJSON(1.5e3) error = %v, want ErrInvalidAmount", err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		src  any
+		want string
+		err  error
+	}{
+		{src: "12.3400", want: "12.34"},
+		{src: []byte("-7"), want: "-7"},
+		{src: int64(5), want: "5"},
+		{src: nil, want: "0"},
+		{src: int64(1) << 62, err: money.ErrOverflow},
+		{src: 1.5, err: money.ErrInvalidAmount},
+	}
+	for _, tt := range tests {
+		a := money.MustParse("1")
+		err := a.Scan(tt.src)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Scan(%v) error = %v, want %v", tt.src, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || a.String() != tt.want {
+			t.Errorf("Scan(%v) = %s, %v, want %s", tt.src, a, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/events"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+// Staff act on any booking, outside the state machine's party rules. Everything they change is
+// recorded in the booking's history under their id, with the reason they gave.
+
+// actionOverride is recorded when staff set a booking's status directly
+const actionOverride BookingAction = "override"
+
+// MaxBlockedSlotRangeDays caps the window of blocked slots staff may list at once
+const MaxBlockedSlotRangeDays = 366
+
+var (
+	ErrReasonRequired    = apperr.Validation("VALIDATION_ERROR", "a reason is required")
+	ErrAuditNoteRequired = apperr.Validation("VALIDATION_ERROR", "an audit note is required")
+	ErrInvalidStatus     = apperr.Validation("INVALID_STATUS", "unknown booking status")
+
+	ErrBlockedRangeTooLarge = apperr.BadRequest("INVALID_DATE_RANGE", fmt.Sprintf("date range must not exceed %d days", MaxBlockedSlotRangeDays))
+	ErrDeadLetterNotFound   = apperr.NotFound("EVENT_NOT_FOUND", "dead-lettered event not found")
+)
+
+// MaxDeadLetters caps how many dead-lettered events staff may list at once
+const MaxDeadLetters = 200
+
+// forceCancellable are the statuses staff may cancel from; the rest are already final
+var forceCancellable = []string{
+	models.BookingStatusPending,
+	models.BookingStatusConfirmed,
+	models.BookingStatusInProgress,
+	models.BookingStatusAwaitingCompletion,
+}
+
+// statusEvents lists the outbox events sent when staff move a booking into a status
+var statusEvents = map[string]string{
+	models.BookingStatusConfirmed: events.BookingConfirmed,
+	models.BookingStatusDeclined:  events.BookingDeclined,
+	models.BookingStatusCompleted: events.BookingCompleted,
+	models.BookingStatusCancelled: events.BookingCancelled,
+	models.BookingStatusNoShow:    events.BookingNoShow,
+	models.BookingStatusExpired:   events.BookingExpired,
+}
+
+// AdminSearchBookings returns one page of bookings across all users and the cursor of the next
+func (s *BookingService) AdminSearchBookings(ctx context.Context, req *models.AdminBookingsQuery) ([]*models.Booking, string, error) {
+	list := &models.ListBookingsQuery{
+		Status:    req.Status,
+		From:      req.From,
+		To:        req.To,
+		ProjectID: req.ProjectID,
+		Sort:      req.Sort,
+		Limit:     req.Limit,
+		Cursor:    req.Cursor,
+	}
+	q, err := bookingQuery("", list, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	q.WorkerID = req.WorkerID
+	q.ClientID = req.ClientID
+
+	return s.listBookingPage(ctx, q, list.Sort)
+}
+
+func (s *BookingService) AdminGetBooking(ctx context.Context, id string) (*models.Booking, error) {
+	return getAnyBooking(ctx, s.store, id, 0)
+}
+
+func (s *BookingService) AdminGetBookingHistory(ctx context.Context, id string) ([]*models.BookingEvent, error) {
+	if _, err := getAnyBooking(ctx, s.store, id, 0); err != nil {
+		return nil, err
+	}
+	return s.store.History().ListByBooking(ctx, id)
+}
+
+// AdminCancelBooking cancels a booking that has not yet finished, whoever it belongs to. The
+// client is refunded in full.
+func (s *BookingService) AdminCancelBooking(ctx context.Context, id string, staffID string, version int, reason string) (*models.Booking, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	mc := &meetingChanges{}
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		booking, err := getAnyBooking(ctx, tx, id, version)
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(forceCancellable, booking.Status) {
+			return fmt.Errorf("%w: cannot cancel a %s booking", ErrInvalidTransition, booking.Status)
+		}
+
+		syncMeeting(booking, models.BookingStatusCancelled, mc)
+		return cancelBooking(ctx, tx, booking, models.BookingStatusCancelled, staffID, reason, time.Now())
+	})
+	s.settleMeetings(ctx, mc, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AdminGetBooking(ctx, id)
+}
+
+// AdminOverrideStatus sets the booking's status directly, e.g. to repair a booking left in the
+// wrong state. The note is mandatory and becomes the reason in the booking's history. Reviving a
+// cancelled or declined booking fails if its slot has been taken since.
+func (s *BookingService) AdminOverrideStatus(ctx context.Context, id string, staffID string, version int, status string, note string) (*models.Booking, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, ErrAuditNoteRequired
+	}
+	if !slices.Contains(bookingStatuses, status) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidStatus, status)
+	}
+
+	mc := &meetingChanges{}
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		booking, err := getAnyBooking(ctx, tx, id, version)
+		if err != nil {
+			return err
+		}
+		if booking.Status == status {
+			return fmt.Errorf("%w: booking is already %s", ErrInvalidTransition, status)
+		}
+
+		if err := tx.LockWorker(ctx, booking.WorkerID); err != nil {
+			return err
+		}
+
+		// Only a cancellation carries a refund and penalty outcome
+		booking.Cancellation = nil
+		if status == models.BookingStatusCancelled {
+			if booking.Cancellation, err = cancellationOutcome(booking, staffID, time.Now()); err != nil {
+				return err
+			}
+		}
+		syncMeeting(booking, status, mc)
+
+		err = setBookingStatus(ctx, tx, booking, status, staffID, actionOverride, note)
+		if errors.Is(err, store.ErrOverlap) {
+			return ErrSlotUnavailable
+		}
+		if err != nil {
+			return err
+		}
+
+		if eventType, ok := statusEvents[status]; ok {
+			return enqueueBookingEvent(ctx, tx, eventType, id)
+		}
+		return nil
+	})
+	s.settleMeetings(ctx, mc, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.AdminGetBooking(ctx, id)
+}
+
+// getAnyBooking loads a booking whoever it belongs to; a non-zero version must match
+func getAnyBooking(ctx context.Context, st store.Store, id string, version int) (*models.Booking, error) {
+	b, err := st.Bookings().Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrBookingNotFound
+		}
+		return nil, err
+	}
+	if version != 0 && b.Version != version {
+		return nil, ErrVersionMismatch
+	}
+	return b, nil
+}
+
+// AdminGetWorkerCalendar returns the worker's weekly availability and the slots they blocked
+// in [from, to)
+func (s *AvailabilityService) AdminGetWorkerCalendar(ctx context.Context, workerID string, from, to time.Time) (*models.WorkerCalendar, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidDateRange
+	}
+	if to.Sub(from) > MaxBlockedSlotRangeDays*24*time.Hour {
+		return nil, ErrBlockedRangeTooLarge
+	}
+
+	schedule, err := loadWorkerSchedule(ctx, s.store, workerID)
+	if err != nil {
+		return nil, err
+	}
+
+	blocked, err := s.store.BlockedSlots().ListOverlapping(ctx, workerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(blocked, func(a, b *models.BlockedSlot) int {
+		return a.StartTime.Compare(b.StartTime)
+	})
+
+	return &models.WorkerCalendar{
+		WorkerID:        workerID,
+		Timezone:        schedule.Location.String(),
+		Availability:    schedule.Availability,
+		SchedulingRules: schedule.Rules,
+		BlockedSlots:    blocked,
+	}, nil
+}
+
+// AdminListDeadLetters returns up to limit outbox events the relay gave up on, most recent first
+func (s *BookingService) AdminListDeadLetters(ctx context.Context, limit int) ([]*models.DeadLetter, error) {
+	dead, err := s.store.Outbox().ListDeadLettered(ctx, min(limit, MaxDeadLetters))
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]*models.DeadLetter, 0, len(dead))
+	for _, e := range dead {
+		letters = append(letters, &models.DeadLetter{
+			ID:             e.ID,
+			AggregateType:  e.AggregateType,
+			AggregateID:    e.AggregateID,
+			EventType:      e.EventType,
+			Payload:        e.Payload,
+			Attempts:       e.Attempts,
+			LastError:      e.LastError,
+			CreatedAt:      e.CreatedAt,
+			DeadLetteredAt: *e.DeadLetteredAt,
+		})
+	}
+	return letters, nil
+}
+
+// AdminRequeueDeadLetter hands a dead-lettered event back to the relay with a fresh set of
+// attempts, e.g. once the broker problem that stopped it is fixed
+func (s *BookingService) AdminRequeueDeadLetter(ctx context.Context, id string) error {
+	if err := s.store.Outbox().Requeue(ctx, id, time.Now()); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return ErrDeadLetterNotFound
+		}
+		return err
+	}
+	return nil
+}
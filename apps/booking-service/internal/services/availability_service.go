@@ -2,163 +2,165 @@ package services
 
 import (
 	"context"
-	"encoding/json"
-	"errors"
 	"fmt"
-	"os"
 	"time"
 
+	"booking-service/internal/apperr"
 	"booking-service/internal/models"
+	"booking-service/internal/store"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type AvailabilityService struct {
-	db *pgxpool.Pool
+	store store.Store
 }
 
-func NewAvailabilityService() *AvailabilityService {
-	dbURL := os.Getenv("DATABASE_URL")
-	pool, err := pgxpool.New(context.Background(), dbURL)
-	if err != nil {
-		panic(err)
-	}
-
-	return &AvailabilityService{db: pool}
+func NewAvailabilityService(st store.Store) *AvailabilityService {
+	return &AvailabilityService{store: st}
 }
 
 func (s *AvailabilityService) GetWorkerAvailability(ctx context.Context, workerID string) ([]models.AvailabilitySlot, error) {
-	var availabilityJSON []byte
-	err := s.db.QueryRow(ctx, "SELECT availability FROM worker_profiles WHERE user_id = $1", workerID).Scan(&availabilityJSON)
+	return loadAvailability(ctx, s.store, workerID)
+}
+
+// MaxSlotRangeDays caps how many days a single range query may cover
+const MaxSlotRangeDays = 31
+
+var (
+	ErrInvalidDateRange  = apperr.BadRequest("INVALID_DATE_RANGE", "from date must not be after to date")
+	ErrSlotRangeTooLarge = apperr.BadRequest("INVALID_DATE_RANGE", fmt.Sprintf("date range must not exceed %d days", MaxSlotRangeDays))
+)
+
+// GetAvailableSlots generates bookable slots for a calendar date in the worker's timezone.
+// Slots are rendered in displayLoc, or in the worker's timezone when it is nil. Ranges held by
+// clients other than viewerID are left out; viewerID may be empty for anonymous callers.
+func (s *AvailabilityService) GetAvailableSlots(ctx context.Context, workerID string, viewerID string, date time.Time, durationMinutes int, displayLoc *time.Location) ([]models.TimeSlot, *time.Location, error) {
+	days, loc, err := s.GetAvailableSlotsRange(ctx, workerID, viewerID, date, date, durationMinutes, displayLoc)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return []models.AvailabilitySlot{}, nil
-		}
-		return nil, err
+		return nil, nil, err
 	}
+	return days[0].Slots, loc, nil
+}
 
-	if availabilityJSON == nil {
-		return []models.AvailabilitySlot{}, nil
+// GetAvailableSlotsRange generates slots for every calendar date in [from, to], fetching
+// bookings and blocked slots once for the whole window.
+func (s *AvailabilityService) GetAvailableSlotsRange(ctx context.Context, workerID string, viewerID string, from, to time.Time, durationMinutes int, displayLoc *time.Location) ([]models.DaySlots, *time.Location, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if to.Before(from) {
+		return nil, nil, ErrInvalidDateRange
+	}
+	numDays := int(to.Sub(from).Hours()/24) + 1
+	if numDays > MaxSlotRangeDays {
+		return nil, nil, ErrSlotRangeTooLarge
 	}
 
-	var slots []models.AvailabilitySlot
-	if err := json.Unmarshal(availabilityJSON, &slots); err != nil {
-		return []models.AvailabilitySlot{}, nil
+	schedule, err := loadWorkerSchedule(ctx, s.store, workerID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return slots, nil
-}
+	if displayLoc == nil {
+		displayLoc = schedule.Location
+	}
 
-func (s *AvailabilityService) GetAvailableSlots(ctx context.Context, workerID string, date time.Time, durationMinutes int) ([]models.TimeSlot, error) {
-	// Get worker's recurring availability
-	availability, err := s.GetWorkerAvailability(ctx, workerID)
-	if err != nil {
-		return nil, err
+	duration := time.Duration(durationMinutes) * time.Minute
+	if err := checkDuration(schedule.Rules, duration); err != nil {
+		return nil, nil, err
 	}
 
-	if len(availability) == 0 {
-		return []models.TimeSlot{}, nil
+	now := time.Now()
+	var busy []timeRange
+	if len(schedule.Availability) > 0 {
+		window := slotRequest{
+			WorkerID: workerID,
+			Start:    dayBounds(from.Year(), from.Month(), from.Day(), schedule.Location).Start,
+			End:      dayBounds(to.Year(), to.Month(), to.Day(), schedule.Location).End,
+			HolderID: viewerID,
+		}
+		busy, err = loadBusyRanges(ctx, s.store, window, schedule.Rules, now)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	dayOfWeek := int(date.Weekday())
+	days := make([]models.DaySlots, 0, numDays)
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		days = append(days, models.DaySlots{
+			Date:  date.Format("2006-01-02"),
+			Slots: schedule.slotsOn(date.Year(), date.Month(), date.Day(), duration, busy, now, displayLoc),
+		})
+	}
 
-	// Get existing bookings for the day
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	return days, displayLoc, nil
+}
 
-	rows, err := s.db.Query(ctx, `
-		SELECT start_time, end_time FROM bookings 
-		WHERE worker_id = $1 AND start_time >= $2 AND end_time <= $3 AND status NOT IN ('cancelled')
-	`, workerID, startOfDay, endOfDay)
+func (s *AvailabilityService) UpdateAvailability(ctx context.Context, workerID string, slots []models.AvailabilitySlot) error {
+	return s.store.Availability().SetAvailability(ctx, workerID, slots)
+}
+
+func (s *AvailabilityService) GetSchedulingRules(ctx context.Context, workerID string) (*models.SchedulingRules, error) {
+	schedule, err := loadWorkerSchedule(ctx, s.store, workerID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	var bookedSlots []models.TimeSlot
-	for rows.Next() {
-		var slot models.TimeSlot
-		rows.Scan(&slot.StartTime, &slot.EndTime)
-		bookedSlots = append(bookedSlots, slot)
-	}
+	return &schedule.Rules, nil
+}
 
-	// Get blocked slots
-	blockedRows, _ := s.db.Query(ctx, `
-		SELECT start_time, end_time FROM blocked_slots 
-		WHERE worker_id = $1 AND start_time >= $2 AND end_time <= $3
-	`, workerID, startOfDay, endOfDay)
-	defer blockedRows.Close()
+func (s *AvailabilityService) UpdateSchedulingRules(ctx context.Context, workerID string, rules *models.SchedulingRules) error {
+	return s.store.Availability().SetSchedulingRules(ctx, workerID, rules)
+}
 
-	for blockedRows.Next() {
-		var slot models.TimeSlot
-		blockedRows.Scan(&slot.StartTime, &slot.EndTime)
-		bookedSlots = append(bookedSlots, slot)
+func (s *AvailabilityService) GetCancellationPolicy(ctx context.Context, workerID string) (*models.CancellationPolicy, error) {
+	settings, err := s.store.Availability().Get(ctx, workerID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate available slots
-	var availableSlots []models.TimeSlot
+	policy := DefaultCancellationPolicy()
+	if settings.CancellationPolicy != nil {
+		policy = *settings.CancellationPolicy
+	}
+	return &policy, nil
+}
 
-	for _, avail := range availability {
-		if avail.DayOfWeek != dayOfWeek {
-			continue
-		}
+// UpdateCancellationPolicy applies to bookings made from now on; existing bookings keep the
+// policy they were made under
+func (s *AvailabilityService) UpdateCancellationPolicy(ctx context.Context, workerID string, policy *models.CancellationPolicy) error {
+	if err := NormalizeCancellationPolicy(policy); err != nil {
+		return err
+	}
+	return s.store.Availability().SetCancellationPolicy(ctx, workerID, policy)
+}
 
-		startHour, startMin := parseTimeString(avail.StartTime)
-		endHour, endMin := parseTimeString(avail.EndTime)
-
-		slotStart := time.Date(date.Year(), date.Month(), date.Day(), startHour, startMin, 0, 0, date.Location())
-		slotEnd := time.Date(date.Year(), date.Month(), date.Day(), endHour, endMin, 0, 0, date.Location())
-
-		// Generate slots at intervals
-		for current := slotStart; current.Add(time.Duration(durationMinutes)*time.Minute).Before(slotEnd) || current.Add(time.Duration(durationMinutes)*time.Minute).Equal(slotEnd); current = current.Add(30 * time.Minute) {
-			proposedEnd := current.Add(time.Duration(durationMinutes) * time.Minute)
-
-			// Check if slot conflicts with any booked slots
-			isAvailable := true
-			for _, booked := range bookedSlots {
-				if !(proposedEnd.Before(booked.StartTime) || proposedEnd.Equal(booked.StartTime) || current.After(booked.EndTime) || current.Equal(booked.EndTime)) {
-					isAvailable = false
-					break
-				}
-			}
-
-			if isAvailable {
-				availableSlots = append(availableSlots, models.TimeSlot{
-					StartTime:   current,
-					EndTime:     proposedEnd,
-					IsAvailable: true,
-				})
-			}
-		}
+// GetRateCard returns the worker's rate card with the hourly rate they are actually priced at
+func (s *AvailabilityService) GetRateCard(ctx context.Context, workerID string) (*models.RateCard, error) {
+	settings, err := s.store.Availability().Get(ctx, workerID)
+	if err != nil {
+		return nil, err
 	}
 
-	return availableSlots, nil
+	card := workerRateCard(settings)
+	if card.HourlyRate == nil {
+		card.HourlyRate = settings.HourlyRate
+	}
+	return &card, nil
 }
 
-func (s *AvailabilityService) UpdateAvailability(ctx context.Context, workerID string, slots []models.AvailabilitySlot) error {
-	slotsJSON, _ := json.Marshal(slots)
-
-	// Use upsert: worker_profiles row may not exist yet
-	_, err := s.db.Exec(ctx, `
-		INSERT INTO worker_profiles (user_id, availability)
-		VALUES ($1, $2)
-		ON CONFLICT (user_id) DO UPDATE SET availability = $2
-	`, workerID, slotsJSON)
-	return err
+// UpdateRateCard applies to bookings made from now on; existing bookings keep the price they
+// were booked at
+func (s *AvailabilityService) UpdateRateCard(ctx context.Context, workerID string, card *models.RateCard) error {
+	if err := NormalizeRateCard(card); err != nil {
+		return err
+	}
+	return s.store.Availability().SetRateCard(ctx, workerID, card)
 }
 
 func (s *AvailabilityService) BlockTimeSlot(ctx context.Context, workerID string, req *models.BlockedSlot) (*models.BlockedSlot, error) {
-	id := uuid.New().String()
-	req.ID = id
+	req.ID = uuid.New().String()
 
-	_, err := s.db.Exec(ctx, `
-		INSERT INTO blocked_slots (id, worker_id, start_time, end_time, reason, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-	`, id, workerID, req.StartTime, req.EndTime, req.Reason, time.Now())
-
-	if err != nil {
+	if err := s.store.BlockedSlots().Create(ctx, workerID, req); err != nil {
 		return nil, err
 	}
 
@@ -166,8 +168,7 @@ func (s *AvailabilityService) BlockTimeSlot(ctx context.Context, workerID string
 }
 
 func (s *AvailabilityService) UnblockTimeSlot(ctx context.Context, workerID string, slotID string) error {
-	_, err := s.db.Exec(ctx, "DELETE FROM blocked_slots WHERE id = $1 AND worker_id = $2", slotID, workerID)
-	return err
+	return s.store.BlockedSlots().Delete(ctx, workerID, slotID)
 }
 
 func parseTimeString(t string) (int, int) {
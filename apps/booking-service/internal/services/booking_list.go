@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultBookingPageSize = 20
+	MaxBookingPageSize     = 100
+)
+
+var (
+	ErrInvalidBookingFilter = apperr.BadRequest("INVALID_FILTER", "invalid booking filter")
+	ErrInvalidCursor        = apperr.BadRequest("INVALID_CURSOR", "invalid or expired cursor")
+)
+
+var bookingStatuses = []string{
+	models.BookingStatusPending,
+	models.BookingStatusConfirmed,
+	models.BookingStatusInProgress,
+	models.BookingStatusCompleted,
+	models.BookingStatusCancelled,
+	models.BookingStatusDeclined,
+	models.BookingStatusNoShow,
+	models.BookingStatusExpired,
+	models.BookingStatusAwaitingCompletion,
+}
+
+// bookingCursor is the opaque nextCursor handed to clients. It records the sort it was issued
+// for so it can't be replayed against a different ordering.
+type bookingCursor struct {
+	Sort  string    `json:"s"`
+	Value time.Time `json:"v"`
+	ID    string    `json:"id"`
+}
+
+// ListBookings returns one page of the user's bookings and the cursor of the next page, which is
+// empty on the last page. Without a limit or cursor it returns every booking, as the endpoint
+// did before it was paginated.
+func (s *BookingService) ListBookings(ctx context.Context, userID string, req *models.ListBookingsQuery) ([]*models.Booking, string, error) {
+	q, err := bookingQuery(userID, req, time.Now())
+	if err != nil {
+		return nil, "", err
+	}
+	if !PagedBookingList(req) {
+		q.Limit = 0
+		bookings, err := s.store.Bookings().List(ctx, q)
+		return bookings, "", err
+	}
+	return s.listBookingPage(ctx, q, req.Sort)
+}
+
+// PagedBookingList reports whether the client asked for a page of bookings rather than all of them
+func PagedBookingList(req *models.ListBookingsQuery) bool {
+	return req.Limit > 0 || req.Cursor != ""
+}
+
+// listBookingPage runs q and returns the page with the cursor of the next one, issued for sort
+func (s *BookingService) listBookingPage(ctx context.Context, q store.BookingQuery, sort string) ([]*models.Booking, string, error) {
+	// Fetch one extra row to learn whether another page follows
+	limit := q.Limit
+	q.Limit++
+
+	bookings, err := s.store.Bookings().List(ctx, q)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(bookings) <= limit {
+		return bookings, "", nil
+	}
+
+	bookings = bookings[:limit]
+	last := bookings[limit-1]
+	next := bookingCursor{Sort: sort, Value: last.StartTime, ID: last.ID}
+	if q.SortField == store.SortCreatedAt {
+		next.Value = last.CreatedAt
+	}
+
+	return bookings, encodeCursor(next), nil
+}
+
+func bookingQuery(userID string, req *models.ListBookingsQuery, now time.Time) (store.BookingQuery, error) {
+	if req.Sort == "" {
+		req.Sort = "-startTime"
+	}
+
+	q := store.BookingQuery{
+		UserID:         userID,
+		Role:           req.Role,
+		StartsFrom:     req.From,
+		StartsBefore:   req.To,
+		CounterpartyID: req.CounterpartyID,
+		ProjectID:      req.ProjectID,
+		SortField:      store.SortStartTime,
+		Descending:     strings.HasPrefix(req.Sort, "-"),
+		Limit:          req.Limit,
+	}
+	if strings.TrimPrefix(req.Sort, "-") == "createdAt" {
+		q.SortField = store.SortCreatedAt
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultBookingPageSize
+	}
+	q.Limit = min(q.Limit, MaxBookingPageSize)
+
+	for _, value := range req.Status {
+		for _, status := range strings.Split(value, ",") {
+			status = strings.TrimSpace(status)
+			if status == "" {
+				continue
+			}
+			if !slices.Contains(bookingStatuses, status) {
+				return q, fmt.Errorf("%w: unknown status %q", ErrInvalidBookingFilter, status)
+			}
+			q.Statuses = append(q.Statuses, status)
+		}
+	}
+
+	if !req.From.IsZero() && !req.To.IsZero() && !req.To.After(req.From) {
+		return q, fmt.Errorf("%w: to must be after from", ErrInvalidBookingFilter)
+	}
+
+	switch req.When {
+	case "upcoming":
+		q.EndsAfter = now
+	case "past":
+		q.EndedBy = now
+	}
+
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil || cursor.Sort != req.Sort {
+			return q, ErrInvalidCursor
+		}
+		q.After = &store.BookingCursor{Value: cursor.Value, ID: cursor.ID}
+	}
+
+	return q, nil
+}
+
+func encodeCursor(c bookingCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*bookingCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &bookingCursor{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, err
+	}
+	// The ID is compared with booking IDs in the keyset condition, so it must be a UUID
+	if _, err := uuid.Parse(c.ID); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	value := time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC)
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+		valid  bool
+	}{
+		{name: "encoded cursor", cursor: encodeCursor(bookingCursor{Sort: "startTime", Value: value, ID: testClientID}), valid: true},
+		{name: "not base64", cursor: "%%%"},
+		{name: "not JSON", cursor: raw("cursor")},
+		{name: "no ID", cursor: raw(`{"s":"startTime","v":"2026-10-20T10:00:00Z"}`)},
+		{name: "ID is not a UUID", cursor: raw(`{"s":"startTime","v":"2026-10-20T10:00:00Z","id":"42"}`)},
+	}
+	for _, tt := range tests {
+		c, err := decodeCursor(tt.cursor)
+		if tt.valid {
+			if err != nil || c.ID != testClientID || !c.Value.Equal(value) {
+				t.Errorf("%s: decodeCursor = %+v, %v", tt.name, c, err)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%s: decodeCursor = %+v, want an error", tt.name, c)
+		}
+	}
+
+	if _, err := decodeCursor(raw(`{"id":"42"}`)); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("error = %v, want %v", err, ErrInvalidCursor)
+	}
+}
@@ -0,0 +1,455 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/events"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSeriesNotFound     = apperr.NotFound("NOT_FOUND", "booking series not found")
+	ErrBookingNotInSeries = apperr.Validation("VALIDATION_ERROR", "booking does not belong to this series")
+	ErrSeriesScope        = apperr.Validation("VALIDATION_ERROR", "bookingId is required for this scope")
+	// ErrSeriesConflict carries the occurrences that could not be booked as its details
+	ErrSeriesConflict = apperr.Conflict("SERIES_CONFLICT", "occurrences conflict with the worker's calendar")
+)
+
+func seriesConflict(conflicts []models.SeriesConflict) error {
+	err := ErrSeriesConflict.WithDetails(conflicts)
+	err.Message = fmt.Sprintf("%d occurrence(s) conflict with the worker's calendar", len(conflicts))
+	return err
+}
+
+func (s *BookingService) CreateBookingSeries(ctx context.Context, clientID string, req *models.CreateBookingSeriesRequest) (*models.BookingSeries, error) {
+	if !req.EndTime.After(req.StartTime) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	rule, err := ParseRecurrenceRule(req.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	exceptions := make(map[string]bool, len(req.ExceptionDates))
+	for _, date := range req.ExceptionDates {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("%w: exception dates must be formatted as YYYY-MM-DD", ErrInvalidRecurrence)
+		}
+		exceptions[date] = true
+	}
+
+	seriesID := uuid.New().String()
+	err = s.store.WithTx(ctx, func(tx store.Store) error {
+		if err := tx.LockWorker(ctx, req.WorkerID); err != nil {
+			return err
+		}
+
+		schedule, err := loadWorkerSchedule(ctx, tx, req.WorkerID)
+		if err != nil {
+			return err
+		}
+
+		settings, err := tx.Availability().Get(ctx, req.WorkerID)
+		if err != nil {
+			return err
+		}
+		rate, currency, err := workerRate(settings)
+		if err != nil {
+			return err
+		}
+
+		// Occurrences follow the worker's wall clock, since that is where availability is defined
+		starts, err := rule.Occurrences(req.StartTime, schedule.Location)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		duration := req.EndTime.Sub(req.StartTime)
+		series := &models.BookingSeries{
+			ID:             seriesID,
+			WorkerID:       req.WorkerID,
+			ClientID:       clientID,
+			ProjectID:      req.ProjectID,
+			Title:          req.Title,
+			Description:    req.Description,
+			RRule:          rule.String(),
+			StartTime:      req.StartTime,
+			Duration:       wholeMinutes(req.StartTime, req.EndTime),
+			HourlyRate:     rate,
+			Currency:       currency,
+			Timezone:       schedule.Location.String(),
+			ExceptionDates: slices.Clone(req.ExceptionDates),
+			Status:         models.SeriesStatusActive,
+			Notes:          req.Notes,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if series.ExceptionDates == nil {
+			series.ExceptionDates = []string{}
+		}
+
+		if err := tx.Series().Create(ctx, series); err != nil {
+			return err
+		}
+
+		var conflicts []models.SeriesConflict
+		created := 0
+		for _, start := range starts {
+			date := start.In(schedule.Location).Format("2006-01-02")
+			if exceptions[date] {
+				continue
+			}
+
+			end := start.Add(duration)
+			if err := ensureBookable(ctx, tx, slotRequest{WorkerID: req.WorkerID, Start: start, End: end, HolderID: clientID}, now); err != nil {
+				if !isSlotError(err) {
+					return err
+				}
+				conflicts = append(conflicts, models.SeriesConflict{StartTime: start, EndTime: end, Reason: err.Error()})
+				if req.SkipConflicts {
+					series.ExceptionDates = append(series.ExceptionDates, date)
+				}
+				continue
+			}
+
+			booking := newBooking(clientID, &models.CreateBookingRequest{
+				WorkerID:    req.WorkerID,
+				ProjectID:   req.ProjectID,
+				Title:       req.Title,
+				Description: req.Description,
+				StartTime:   start,
+				EndTime:     end,
+				IsRemote:    req.IsRemote,
+				Notes:       req.Notes,
+			}, now)
+			booking.SeriesID = &series.ID
+
+			if err := insertBooking(ctx, tx, booking); err != nil {
+				return err
+			}
+			if err := enqueueBookingEvent(ctx, tx, events.BookingCreated, booking.ID); err != nil {
+				return err
+			}
+			created++
+		}
+
+		if created == 0 || (len(conflicts) > 0 && !req.SkipConflicts) {
+			return seriesConflict(conflicts)
+		}
+
+		if len(conflicts) > 0 {
+			return tx.Series().Update(ctx, series)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBookingSeries(ctx, seriesID, clientID)
+}
+
+func (s *BookingService) GetBookingSeries(ctx context.Context, id string, userID string) (*models.BookingSeries, error) {
+	series, err := getSeries(ctx, s.store, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	series.Occurrences, err = s.store.Bookings().ListBySeries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// UpdateBookingSeries edits the details of one occurrence, an occurrence and everything after it,
+// or the whole series. Occurrences edited on their own are exceptions and keep their edits.
+func (s *BookingService) UpdateBookingSeries(ctx context.Context, id string, userID string, req *models.UpdateBookingSeriesRequest) (*models.BookingSeries, error) {
+	var skipped []models.SkippedOccurrence
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		series, err := getSeries(ctx, tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		targets, pivot, err := s.seriesTargets(ctx, tx, series, req.Scope, req.BookingID)
+		if err != nil {
+			return err
+		}
+
+		if req.Scope == models.SeriesScopeThis {
+			if _, err := s.states.Apply(pivot, ActionUpdate, userID); err != nil {
+				return err
+			}
+			before := *pivot
+			applyBookingDetails(pivot, req.Title, req.Description, req.Notes)
+			pivot.SeriesException = true
+			return updateBooking(ctx, tx, &before, pivot, userID, ActionUpdate)
+		}
+
+		target := series
+		if req.Scope == models.SeriesScopeFollowing && pivot.StartTime.After(series.StartTime) {
+			target, targets, skipped, err = s.splitSeries(ctx, tx, series, pivot, targets, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if req.Title != nil {
+			target.Title = *req.Title
+		}
+		if req.Description != nil {
+			target.Description = *req.Description
+		}
+		if req.Notes != nil {
+			target.Notes = req.Notes
+		}
+		target.UpdatedAt = time.Now()
+		if err := tx.Series().Update(ctx, target); err != nil {
+			return err
+		}
+
+		for _, b := range targets {
+			if b.SeriesException {
+				continue
+			}
+			// Occurrences that are no longer editable keep their details
+			if _, err := s.states.Apply(b, ActionUpdate, userID); err != nil {
+				if !errors.Is(err, ErrInvalidTransition) {
+					return err
+				}
+				skipped = append(skipped, skippedOccurrence(b, err))
+				continue
+			}
+			before := *b
+			applyBookingDetails(b, req.Title, req.Description, req.Notes)
+			if err := updateBooking(ctx, tx, &before, b, userID, ActionUpdate); err != nil {
+				return err
+			}
+		}
+		id = target.ID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getSeriesWithSkipped(ctx, id, userID, skipped)
+}
+
+// CancelBookingSeries cancels one occurrence, an occurrence and everything after it, or the whole
+// series. Occurrences that can no longer be cancelled, such as completed ones, are left alone and
+// reported in the series' Skipped list.
+func (s *BookingService) CancelBookingSeries(ctx context.Context, id string, userID string, req *models.CancelBookingSeriesRequest) (*models.BookingSeries, error) {
+	var skipped []models.SkippedOccurrence
+	mc := &meetingChanges{}
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		series, err := getSeries(ctx, tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		targets, pivot, err := s.seriesTargets(ctx, tx, series, req.Scope, req.BookingID)
+		if err != nil {
+			return err
+		}
+
+		if req.Scope == models.SeriesScopeThis {
+			status, err := s.states.Apply(pivot, ActionCancel, userID)
+			if err != nil {
+				return err
+			}
+			syncMeeting(pivot, status, mc)
+			return cancelBooking(ctx, tx, pivot, status, userID, req.Reason, time.Now())
+		}
+
+		for _, b := range targets {
+			status, err := s.states.Apply(b, ActionCancel, userID)
+			if errors.Is(err, ErrInvalidTransition) {
+				skipped = append(skipped, skippedOccurrence(b, err))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			syncMeeting(b, status, mc)
+			if err := cancelBooking(ctx, tx, b, status, userID, req.Reason, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		if req.Scope == models.SeriesScopeAll || !pivot.StartTime.After(series.StartTime) {
+			series.Status = models.SeriesStatusCancelled
+			series.UpdatedAt = time.Now()
+			return tx.Series().Update(ctx, series)
+		}
+		return truncateSeries(ctx, tx, series, pivot.StartTime)
+	})
+	s.settleMeetings(ctx, mc, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.getSeriesWithSkipped(ctx, id, userID, skipped)
+}
+
+// getSeriesWithSkipped returns the series along with the occurrences an operation skipped
+func (s *BookingService) getSeriesWithSkipped(ctx context.Context, id string, userID string, skipped []models.SkippedOccurrence) (*models.BookingSeries, error) {
+	series, err := s.GetBookingSeries(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	series.Skipped = skipped
+	return series, nil
+}
+
+func skippedOccurrence(b *models.Booking, err error) models.SkippedOccurrence {
+	return models.SkippedOccurrence{BookingID: b.ID, StartTime: b.StartTime, Status: b.Status, Reason: err.Error()}
+}
+
+// seriesTargets resolves the occurrences a scoped series operation applies to, along with the
+// occurrence the caller pointed at. For the "all" scope the pivot is the first occurrence.
+func (s *BookingService) seriesTargets(ctx context.Context, st store.Store, series *models.BookingSeries, scope string, bookingID string) ([]*models.Booking, *models.Booking, error) {
+	occurrences, err := st.Bookings().ListBySeries(ctx, series.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if scope == models.SeriesScopeAll {
+		if len(occurrences) == 0 {
+			return occurrences, &models.Booking{StartTime: series.StartTime}, nil
+		}
+		return occurrences, occurrences[0], nil
+	}
+
+	if bookingID == "" {
+		return nil, nil, ErrSeriesScope
+	}
+
+	for i, b := range occurrences {
+		if b.ID != bookingID {
+			continue
+		}
+		if scope == models.SeriesScopeThis {
+			return []*models.Booking{b}, b, nil
+		}
+		return occurrences[i:], b, nil
+	}
+
+	return nil, nil, ErrBookingNotInSeries
+}
+
+// splitSeries ends the series before pivot and moves the following occurrences, pivot included,
+// to a new series that continues the same recurrence. It returns the new series along with the
+// occurrences it moved; those that can no longer be updated stay on the old series and are skipped.
+func (s *BookingService) splitSeries(ctx context.Context, st store.Store, series *models.BookingSeries, pivot *models.Booking, following []*models.Booking, actorID string) (*models.BookingSeries, []*models.Booking, []models.SkippedOccurrence, error) {
+	rule, err := ParseRecurrenceRule(series.RRule)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	loc, err := time.LoadLocation(series.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	nextRule := *rule
+	if rule.Count > 0 {
+		starts, err := rule.Occurrences(series.StartTime, loc)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		remaining := 0
+		for _, start := range starts {
+			if !start.Before(pivot.StartTime) {
+				remaining++
+			}
+		}
+		nextRule.Count = max(remaining, 1)
+	}
+
+	now := time.Now()
+	next := *series
+	next.ID = uuid.New().String()
+	next.ParentSeriesID = &series.ID
+	next.RRule = nextRule.String()
+	next.StartTime = pivot.StartTime
+	next.ExceptionDates = slices.Clone(series.ExceptionDates)
+	next.CreatedAt = now
+	next.UpdatedAt = now
+
+	if err := st.Series().Create(ctx, &next); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var moved []*models.Booking
+	var skipped []models.SkippedOccurrence
+	for _, b := range following {
+		if _, err := s.states.Apply(b, ActionUpdate, actorID); err != nil {
+			if !errors.Is(err, ErrInvalidTransition) {
+				return nil, nil, nil, err
+			}
+			skipped = append(skipped, skippedOccurrence(b, err))
+			continue
+		}
+		before := *b
+		b.SeriesID = &next.ID
+		if err := updateBooking(ctx, st, &before, b, actorID, ActionUpdate); err != nil {
+			return nil, nil, nil, err
+		}
+		moved = append(moved, b)
+	}
+
+	if err := truncateSeries(ctx, st, series, pivot.StartTime); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &next, moved, skipped, nil
+}
+
+// truncateSeries rewrites the series rule so it ends before the given occurrence
+func truncateSeries(ctx context.Context, st store.Store, series *models.BookingSeries, before time.Time) error {
+	rule, err := ParseRecurrenceRule(series.RRule)
+	if err != nil {
+		return err
+	}
+	rule.Count = 0
+	rule.Until = before.Add(-time.Second).UTC()
+
+	series.RRule = rule.String()
+	series.UpdatedAt = time.Now()
+	return st.Series().Update(ctx, series)
+}
+
+// getSeries loads a series visible to userID, i.e. one where they are the worker or the client
+func getSeries(ctx context.Context, st store.Store, id string, userID string) (*models.BookingSeries, error) {
+	series, err := st.Series().Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrSeriesNotFound
+		}
+		return nil, err
+	}
+
+	if series.WorkerID != userID && series.ClientID != userID {
+		return nil, ErrSeriesNotFound
+	}
+	return series, nil
+}
+
+// isSlotError reports whether err means the requested time cannot be booked, as opposed to a
+// failure talking to the database
+func isSlotError(err error) bool {
+	return errors.Is(err, ErrSlotUnavailable) || errors.Is(err, ErrOutsideAvailability) || errors.Is(err, ErrSchedulingRule) || errors.Is(err, ErrInvalidTimeRange)
+}
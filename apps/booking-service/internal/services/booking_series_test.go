@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/money"
+	"booking-service/internal/store/memory"
+)
+
+const (
+	testWorkerID = "11111111-1111-1111-1111-111111111111"
+	testClientID = "22222222-2222-2222-2222-222222222222"
+	testStaffID  = "33333333-3333-3333-3333-333333333333"
+)
+
+// newTestService runs a BookingService on an in-memory store, for a worker who charges 40 an hour
+// and is available 09:00-17:00 UTC every day
+func newTestService(t *testing.T) (*BookingService, *memory.Store) {
+	t.Helper()
+	ctx := context.Background()
+	st := memory.New()
+
+	slots := make([]models.AvailabilitySlot, 0, 7)
+	for day := range 7 {
+		slots = append(slots, models.AvailabilitySlot{DayOfWeek: day, StartTime: "09:00", EndTime: "17:00", IsRecurring: true})
+	}
+	if err := st.Availability().SetAvailability(ctx, testWorkerID, slots); err != nil {
+		t.Fatal(err)
+	}
+	rate := money.MustParse("40")
+	card := &models.RateCard{HourlyRate: &rate}
+	if err := NormalizeRateCard(card); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Availability().SetRateCard(ctx, testWorkerID, card); err != nil {
+		t.Fatal(err)
+	}
+	return NewBookingService(st), st
+}
+
+// daysAhead is hour o'clock UTC the given number of days from today
+func daysAhead(days, hour int) time.Time {
+	day := time.Now().UTC().AddDate(0, 0, days)
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, time.UTC)
+}
+
+func TestCancelBookingSeriesReportsSkippedOccurrences(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t)
+
+	start := daysAhead(7, 10)
+	series, err := s.CreateBookingSeries(ctx, testClientID, &models.CreateBookingSeriesRequest{
+		WorkerID:  testWorkerID,
+		Title:     "Weekly session",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		RRule:     "FREQ=WEEKLY;COUNT=3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series.Occurrences) != 3 {
+		t.Fatalf("series has %d occurrences, want 3", len(series.Occurrences))
+	}
+
+	// The second occurrence can no longer be cancelled
+	done := series.Occurrences[1]
+	if _, err := s.AdminOverrideStatus(ctx, done.ID, testStaffID, 0, models.BookingStatusCompleted, "settled offline"); err != nil {
+		t.Fatal(err)
+	}
+
+	series, err = s.CancelBookingSeries(ctx, series.ID, testClientID, &models.CancelBookingSeriesRequest{Scope: models.SeriesScopeAll, Reason: "moving away"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(series.Skipped) != 1 || series.Skipped[0].BookingID != done.ID || series.Skipped[0].Status != models.BookingStatusCompleted {
+		t.Fatalf("skipped = %+v, want only the completed occurrence %s", series.Skipped, done.ID)
+	}
+	for _, b := range series.Occurrences {
+		want := models.BookingStatusCancelled
+		if b.ID == done.ID {
+			want = models.BookingStatusCompleted
+		}
+		if b.Status != want {
+			t.Errorf("occurrence %s is %s, want %s", b.ID, b.Status, want)
+		}
+	}
+}
+
+func TestUpdateBookingSeriesReportsSkippedOccurrences(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newTestService(t)
+
+	start := daysAhead(7, 10)
+	series, err := s.CreateBookingSeries(ctx, testClientID, &models.CreateBookingSeriesRequest{
+		WorkerID:  testWorkerID,
+		Title:     "Weekly session",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		RRule:     "FREQ=WEEKLY;COUNT=2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only pending bookings can be edited
+	confirmed := series.Occurrences[0]
+	if _, err := s.ConfirmBooking(ctx, confirmed.ID, testWorkerID, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	title := "Renamed"
+	series, err = s.UpdateBookingSeries(ctx, series.ID, testClientID, &models.UpdateBookingSeriesRequest{Scope: models.SeriesScopeAll, Title: &title})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(series.Skipped) != 1 || series.Skipped[0].BookingID != confirmed.ID {
+		t.Fatalf("skipped = %+v, want only the confirmed occurrence %s", series.Skipped, confirmed.ID)
+	}
+	if series.Occurrences[0].Title != "Weekly session" || series.Occurrences[1].Title != title {
+		t.Fatalf("titles = %q, %q, want the confirmed occurrence unchanged", series.Occurrences[0].Title, series.Occurrences[1].Title)
+	}
+}
+
+// Splitting a series leaves occurrences that can no longer be updated on the old series
+func TestUpdateFollowingOccurrencesSkipsClosedOnes(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService(t)
+
+	start := daysAhead(7, 10)
+	series, err := s.CreateBookingSeries(ctx, testClientID, &models.CreateBookingSeriesRequest{
+		WorkerID:  testWorkerID,
+		Title:     "Weekly session",
+		StartTime: start,
+		EndTime:   start.Add(time.Hour),
+		RRule:     "FREQ=WEEKLY;COUNT=3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := s.AdminOverrideStatus(ctx, series.Occurrences[2].ID, testStaffID, 0, models.BookingStatusCompleted, "settled offline")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	title := "Renamed"
+	pivot := series.Occurrences[1]
+	next, err := s.UpdateBookingSeries(ctx, series.ID, testClientID, &models.UpdateBookingSeriesRequest{Scope: models.SeriesScopeFollowing, BookingID: pivot.ID, Title: &title})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(next.Skipped) != 1 || next.Skipped[0].BookingID != done.ID {
+		t.Fatalf("skipped = %+v, want only the completed occurrence %s", next.Skipped, done.ID)
+	}
+	if len(next.Occurrences) != 1 || next.Occurrences[0].ID != pivot.ID || next.Occurrences[0].Title != title {
+		t.Fatalf("new series holds %+v, want only the renamed occurrence %s", next.Occurrences, pivot.ID)
+	}
+
+	stored, err := st.Bookings().Get(ctx, done.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.SeriesID == nil || *stored.SeriesID != series.ID || stored.Version != done.Version || stored.Title != "Weekly session" {
+		t.Errorf("completed occurrence is on series %v at version %d titled %q, want it untouched on %s", stored.SeriesID, stored.Version, stored.Title, series.ID)
+	}
+}
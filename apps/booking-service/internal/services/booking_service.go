@@ -3,38 +3,100 @@ package services
 import (
 	"context"
 	"errors"
-	"os"
 	"time"
 
+	"booking-service/internal/apperr"
+	"booking-service/internal/events"
 	"booking-service/internal/models"
+	"booking-service/internal/store"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	ErrBookingNotFound = apperr.NotFound("NOT_FOUND", "booking not found")
+	// ErrVersionMismatch means the client's If-Match version is no longer the booking's version
+	ErrVersionMismatch = apperr.PreconditionFailed("PRECONDITION_FAILED", "booking has changed since it was fetched")
+	// ErrConcurrentModification means another request changed the booking while this one was
+	// applying its change
+	ErrConcurrentModification = apperr.Conflict("CONCURRENT_MODIFICATION", "booking was modified by another request")
 )
 
 type BookingService struct {
-	db *pgxpool.Pool
+	store  store.Store
+	states *BookingStateMachine
+	// HoldTTL is how long a slot hold lasts
+	HoldTTL time.Duration
+	// PendingTTL and CompletionGrace time the lifecycle scheduler's transitions
+	PendingTTL      time.Duration
+	CompletionGrace time.Duration
+	// Meetings opens rooms for remote bookings; without one they get no meeting URL
+	Meetings MeetingProvider
 }
 
-func NewBookingService() *BookingService {
-	dbURL := os.Getenv("DATABASE_URL")
-	pool, err := pgxpool.New(context.Background(), dbURL)
-	if err != nil {
-		panic(err)
+func NewBookingService(st store.Store) *BookingService {
+	return &BookingService{
+		store:           st,
+		states:          NewBookingStateMachine(),
+		HoldTTL:         DefaultHoldTTL,
+		PendingTTL:      DefaultPendingTTL,
+		CompletionGrace: DefaultCompletionGrace,
 	}
-
-	return &BookingService{db: pool}
 }
 
+// CreateBooking books a slot for the client. Booking from a hold uses up the hold.
 func (s *BookingService) CreateBooking(ctx context.Context, clientID string, req *models.CreateBookingRequest) (*models.Booking, error) {
-	id := uuid.New().String()
 	now := time.Now()
+	var booking *models.Booking
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		if req.HoldID != nil {
+			hold, err := getHold(ctx, tx, *req.HoldID, clientID, now)
+			if err != nil {
+				return err
+			}
+			if err := applyHold(hold, req); err != nil {
+				return err
+			}
+			if err := tx.Holds().Delete(ctx, hold.ID); err != nil {
+				return err
+			}
+		}
 
-	duration := int(req.EndTime.Sub(req.StartTime).Minutes())
-	totalAmount := float64(duration) / 60.0 * req.HourlyRate
+		if !req.EndTime.After(req.StartTime) {
+			return ErrInvalidTimeRange
+		}
+		booking = newBooking(clientID, req, now)
 
-	booking := &models.Booking{
-		ID:          id,
+		if err := tx.LockWorker(ctx, booking.WorkerID); err != nil {
+			return err
+		}
+
+		slot := slotRequest{WorkerID: booking.WorkerID, Start: booking.StartTime, End: booking.EndTime, HolderID: clientID}
+		if err := ensureBookable(ctx, tx, slot, now); err != nil {
+			return err
+		}
+
+		if err := insertBooking(ctx, tx, booking); err != nil {
+			return err
+		}
+
+		return enqueueBookingEvent(ctx, tx, events.BookingCreated, booking.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Return the fully enriched booking with user data
+	return s.GetBookingByID(ctx, booking.ID, clientID)
+}
+
+func newBooking(clientID string, req *models.CreateBookingRequest, now time.Time) *models.Booking {
+	duration := wholeMinutes(req.StartTime, req.EndTime)
+
+	// The price is filled in by insertBooking from the worker's rate card
+	return &models.Booking{
+		ID:          uuid.New().String(),
 		WorkerID:    req.WorkerID,
 		ClientID:    clientID,
 		ProjectID:   req.ProjectID,
@@ -43,151 +105,127 @@ func (s *BookingService) CreateBooking(ctx context.Context, clientID string, req
 		StartTime:   req.StartTime,
 		EndTime:     req.EndTime,
 		Duration:    duration,
-		HourlyRate:  req.HourlyRate,
-		TotalAmount: totalAmount,
-		Currency:    "USD",
-		Status:      "pending",
+		Status:      models.BookingStatusPending,
+		IsRemote:    req.IsRemote,
 		Notes:       req.Notes,
 		CreatedAt:   now,
 		UpdatedAt:   now,
 	}
+}
 
-	_, err := s.db.Exec(ctx, `
-		INSERT INTO bookings (id, worker_id, client_id, project_id, title, description, start_time, end_time, duration, hourly_rate, total_amount, currency, status, notes, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
-	`, booking.ID, booking.WorkerID, booking.ClientID, booking.ProjectID, booking.Title, booking.Description, booking.StartTime, booking.EndTime, booking.Duration, booking.HourlyRate, booking.TotalAmount, booking.Currency, booking.Status, booking.Notes, booking.CreatedAt, booking.UpdatedAt)
-
-	if err != nil {
-		return nil, err
-	}
+// GetUserBookings returns every booking where the user is the worker (role "worker") or the
+// client, latest first
+func (s *BookingService) GetUserBookings(ctx context.Context, userID string, role string) ([]*models.Booking, error) {
+	return s.store.Bookings().List(ctx, store.BookingQuery{UserID: userID, Role: role, SortField: store.SortStartTime, Descending: true})
+}
 
-	// Return the fully enriched booking with user data
-	return s.GetBookingByID(ctx, booking.ID, clientID)
+func (s *BookingService) GetBookingByID(ctx context.Context, id string, userID string) (*models.Booking, error) {
+	return getBooking(ctx, s.store, id, userID)
 }
 
-func (s *BookingService) GetUserBookings(ctx context.Context, userID string, role string) ([]*models.Booking, error) {
-	query := `
-		SELECT b.id, b.worker_id, b.client_id, b.project_id, b.title, b.description,
-		       b.start_time, b.end_time, b.duration, b.hourly_rate, b.total_amount,
-		       b.currency, b.status, b.meeting_url, b.notes, b.created_at, b.updated_at,
-		       w.id, w.first_name, w.last_name, w.avatar_url,
-		       c.id, c.first_name, c.last_name, c.avatar_url
-		FROM bookings b
-		LEFT JOIN users w ON b.worker_id = w.id
-		LEFT JOIN users c ON b.client_id = c.id
-		WHERE `
-	if role == "worker" {
-		query += "b.worker_id = $1"
-	} else {
-		query += "b.client_id = $1"
-	}
-	query += " ORDER BY b.start_time DESC"
-
-	rows, err := s.db.Query(ctx, query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var bookings []*models.Booking = make([]*models.Booking, 0)
-	for rows.Next() {
-		b := &models.Booking{}
-		w := &models.UserInfo{}
-		c := &models.UserInfo{}
-		err := rows.Scan(
-			&b.ID, &b.WorkerID, &b.ClientID, &b.ProjectID, &b.Title, &b.Description,
-			&b.StartTime, &b.EndTime, &b.Duration, &b.HourlyRate, &b.TotalAmount,
-			&b.Currency, &b.Status, &b.MeetingURL, &b.Notes, &b.CreatedAt, &b.UpdatedAt,
-			&w.ID, &w.FirstName, &w.LastName, &w.AvatarUrl,
-			&c.ID, &c.FirstName, &c.LastName, &c.AvatarUrl,
-		)
+// UpdateBooking edits the booking's details. A non-zero version must match the booking's current
+// version, as sent by the client in If-Match; the same goes for the transitions below.
+func (s *BookingService) UpdateBooking(ctx context.Context, id string, userID string, version int, req *models.UpdateBookingRequest) (*models.Booking, error) {
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		booking, err := getBookingVersion(ctx, tx, id, userID, version)
 		if err != nil {
-			continue
+			return err
 		}
-		if w.ID != "" {
-			b.Worker = w
-		}
-		if c.ID != "" {
-			b.Client = c
+
+		if _, err := s.states.Apply(booking, ActionUpdate, userID); err != nil {
+			return err
 		}
-		bookings = append(bookings, b)
-	}
 
-	return bookings, nil
-}
+		before := *booking
+		applyBookingDetails(booking, req.Title, req.Description, req.Notes)
+		// Bookings get their room when confirmed, so this only decides whether they will
+		if req.IsRemote != nil {
+			booking.IsRemote = *req.IsRemote
+		}
 
-func (s *BookingService) GetBookingByID(ctx context.Context, id string, userID string) (*models.Booking, error) {
-	b := &models.Booking{}
-	w := &models.UserInfo{}
-	c := &models.UserInfo{}
-	err := s.db.QueryRow(ctx, `
-		SELECT b.id, b.worker_id, b.client_id, b.project_id, b.title, b.description,
-		       b.start_time, b.end_time, b.duration, b.hourly_rate, b.total_amount,
-		       b.currency, b.status, b.meeting_url, b.notes, b.created_at, b.updated_at,
-		       w.id, w.first_name, w.last_name, w.avatar_url,
-		       c.id, c.first_name, c.last_name, c.avatar_url
-		FROM bookings b
-		LEFT JOIN users w ON b.worker_id = w.id
-		LEFT JOIN users c ON b.client_id = c.id
-		WHERE b.id = $1 AND (b.worker_id = $2 OR b.client_id = $2)
-	`, id, userID).Scan(
-		&b.ID, &b.WorkerID, &b.ClientID, &b.ProjectID, &b.Title, &b.Description,
-		&b.StartTime, &b.EndTime, &b.Duration, &b.HourlyRate, &b.TotalAmount,
-		&b.Currency, &b.Status, &b.MeetingURL, &b.Notes, &b.CreatedAt, &b.UpdatedAt,
-		&w.ID, &w.FirstName, &w.LastName, &w.AvatarUrl,
-		&c.ID, &c.FirstName, &c.LastName, &c.AvatarUrl,
-	)
+		// An occurrence edited on its own no longer follows series-wide edits
+		if booking.SeriesID != nil {
+			booking.SeriesException = true
+		}
 
+		return updateBooking(ctx, tx, &before, booking, userID, ActionUpdate)
+	})
 	if err != nil {
-		return nil, errors.New("booking not found")
+		return nil, err
 	}
 
-	if w.ID != "" {
-		b.Worker = w
+	return s.GetBookingByID(ctx, id, userID)
+}
+
+// applyBookingDetails overwrites the details that are set and bumps updated_at
+func applyBookingDetails(b *models.Booking, title, description, notes *string) {
+	if title != nil {
+		b.Title = *title
+	}
+	if description != nil {
+		b.Description = *description
 	}
-	if c.ID != "" {
-		b.Client = c
+	if notes != nil {
+		b.Notes = notes
 	}
+	b.UpdatedAt = time.Now()
+}
 
-	return b, nil
+func (s *BookingService) ConfirmBooking(ctx context.Context, id string, userID string, version int) (*models.Booking, error) {
+	return s.transition(ctx, id, userID, version, ActionConfirm, "")
 }
 
-func (s *BookingService) UpdateBooking(ctx context.Context, id string, userID string, req *models.UpdateBookingRequest) (*models.Booking, error) {
-	booking, err := s.GetBookingByID(ctx, id, userID)
-	if err != nil {
-		return nil, err
-	}
+func (s *BookingService) DeclineBooking(ctx context.Context, id string, userID string, version int, reason string) (*models.Booking, error) {
+	return s.transition(ctx, id, userID, version, ActionDecline, reason)
+}
 
-	if booking.Status != "pending" {
-		return nil, errors.New("can only update pending bookings")
-	}
+func (s *BookingService) StartBooking(ctx context.Context, id string, userID string, version int) (*models.Booking, error) {
+	return s.transition(ctx, id, userID, version, ActionStart, "")
+}
 
-	_, err = s.db.Exec(ctx, `
-		UPDATE bookings SET title = COALESCE($1, title), description = COALESCE($2, description), notes = COALESCE($3, notes), updated_at = $4 WHERE id = $5
-	`, req.Title, req.Description, req.Notes, time.Now(), id)
+func (s *BookingService) CancelBooking(ctx context.Context, id string, userID string, version int, reason string) (*models.Booking, error) {
+	return s.transition(ctx, id, userID, version, ActionCancel, reason)
+}
 
-	if err != nil {
-		return nil, err
-	}
+func (s *BookingService) CompleteBooking(ctx context.Context, id string, userID string, version int) (*models.Booking, error) {
+	return s.transition(ctx, id, userID, version, ActionComplete, "")
+}
 
-	return s.GetBookingByID(ctx, id, userID)
+func (s *BookingService) MarkNoShow(ctx context.Context, id string, userID string, version int, reason string) (*models.Booking, error) {
+	return s.transition(ctx, id, userID, version, ActionNoShow, reason)
 }
 
-func (s *BookingService) ConfirmBooking(ctx context.Context, id string, userID string) (*models.Booking, error) {
-	booking, err := s.GetBookingByID(ctx, id, userID)
-	if err != nil {
-		return nil, err
-	}
+// transition applies action to the booking. Confirming a remote booking opens its meeting room,
+// and cancelling or declining one revokes it.
+func (s *BookingService) transition(ctx context.Context, id string, userID string, version int, action BookingAction, reason string) (*models.Booking, error) {
+	mc := &meetingChanges{}
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		booking, err := getBookingVersion(ctx, tx, id, userID, version)
+		if err != nil {
+			return err
+		}
 
-	if booking.WorkerID != userID {
-		return nil, errors.New("only worker can confirm booking")
-	}
+		status, err := s.states.Apply(booking, action, userID)
+		if err != nil {
+			return err
+		}
 
-	if booking.Status != "pending" {
-		return nil, errors.New("can only confirm pending bookings")
-	}
+		syncMeeting(booking, status, mc)
+
+		if action == ActionCancel {
+			return cancelBooking(ctx, tx, booking, status, userID, reason, time.Now())
+		}
 
-	_, err = s.db.Exec(ctx, "UPDATE bookings SET status = $1, updated_at = $2 WHERE id = $3", "confirmed", time.Now(), id)
+		if err := setBookingStatus(ctx, tx, booking, status, userID, action, reason); err != nil {
+			return err
+		}
+
+		if eventType, ok := transitionEvents[action]; ok {
+			return enqueueBookingEvent(ctx, tx, eventType, id)
+		}
+		return nil
+	})
+	s.settleMeetings(ctx, mc, err)
 	if err != nil {
 		return nil, err
 	}
@@ -195,38 +233,105 @@ func (s *BookingService) ConfirmBooking(ctx context.Context, id string, userID s
 	return s.GetBookingByID(ctx, id, userID)
 }
 
-func (s *BookingService) CancelBooking(ctx context.Context, id string, userID string, reason string) (*models.Booking, error) {
-	booking, err := s.GetBookingByID(ctx, id, userID)
+// setBookingStatus saves the booking with its new status, records the change, with the reason,
+// in its history and reschedules its reminders. The write only lands if nobody else has moved the
+// booking on since it was read.
+func setBookingStatus(ctx context.Context, st store.Store, b *models.Booking, status string, actorID string, action BookingAction, reason string) error {
+	before := *b
+	b.Status = status
+	b.UpdatedAt = time.Now()
+	if err := concurrent(st.Bookings().Transition(ctx, b, before.Status)); err != nil {
+		return err
+	}
+	if err := recordBookingEvent(ctx, st, &before, b, actorID, action, reason); err != nil {
+		return err
+	}
+	return scheduleReminders(ctx, st, b)
+}
+
+// getBooking loads a booking visible to userID, i.e. one where they are the worker or the client
+func getBooking(ctx context.Context, st store.Store, id string, userID string) (*models.Booking, error) {
+	b, err := st.Bookings().Get(ctx, id)
 	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrBookingNotFound
+		}
 		return nil, err
 	}
 
-	if booking.Status == "completed" || booking.Status == "cancelled" {
-		return nil, errors.New("cannot cancel this booking")
+	if b.WorkerID != userID && b.ClientID != userID {
+		return nil, ErrBookingNotFound
 	}
+	return b, nil
+}
 
-	_, err = s.db.Exec(ctx, "UPDATE bookings SET status = $1, notes = CONCAT(notes, ' | Cancelled: ', $2), updated_at = $3 WHERE id = $4", "cancelled", reason, time.Now(), id)
+// getBookingVersion is getBooking for a write the client made conditional on version; 0 means
+// unconditional
+func getBookingVersion(ctx context.Context, st store.Store, id string, userID string, version int) (*models.Booking, error) {
+	b, err := getBooking(ctx, st, id, userID)
 	if err != nil {
 		return nil, err
 	}
+	if version != 0 && b.Version != version {
+		return nil, ErrVersionMismatch
+	}
+	return b, nil
+}
 
-	return s.GetBookingByID(ctx, id, userID)
+// updateBooking saves the changes made to b since it was read as before, failing if someone else
+// changed it in the meantime, and records them in the booking's history
+func updateBooking(ctx context.Context, st store.Store, before, b *models.Booking, actorID string, action BookingAction) error {
+	if err := concurrent(st.Bookings().Update(ctx, b)); err != nil {
+		return err
+	}
+	if err := recordBookingEvent(ctx, st, before, b, actorID, action, ""); err != nil {
+		return err
+	}
+	if !b.StartTime.Equal(before.StartTime) {
+		return scheduleReminders(ctx, st, b)
+	}
+	return nil
+}
+
+// concurrent reports a conditional write lost to another request as ErrConcurrentModification
+func concurrent(err error) error {
+	if errors.Is(err, store.ErrConflict) {
+		return ErrConcurrentModification
+	}
+	return err
 }
 
-func (s *BookingService) CompleteBooking(ctx context.Context, id string, userID string) (*models.Booking, error) {
-	booking, err := s.GetBookingByID(ctx, id, userID)
+// insertBooking prices the booking from the worker's rate card and snapshots their current
+// cancellation policy onto it, so later changes to either don't alter the terms the client booked under
+func insertBooking(ctx context.Context, st store.Store, booking *models.Booking) error {
+	settings, err := st.Availability().Get(ctx, booking.WorkerID)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	if err := priceBooking(settings, booking, booking.CreatedAt); err != nil {
+		return err
 	}
 
-	if booking.Status != "confirmed" && booking.Status != "in_progress" {
-		return nil, errors.New("can only complete confirmed or in-progress bookings")
+	policy := DefaultCancellationPolicy()
+	if settings.CancellationPolicy != nil {
+		policy = *settings.CancellationPolicy
 	}
+	booking.CancellationPolicy = &policy
+
+	err = st.Bookings().Create(ctx, booking)
 
-	_, err = s.db.Exec(ctx, "UPDATE bookings SET status = $1, updated_at = $2 WHERE id = $3", "completed", time.Now(), id)
+	// The overlap check in the store is the last line of defence against overlapping bookings
+	if errors.Is(err, store.ErrOverlap) {
+		return ErrSlotUnavailable
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return s.GetBookingByID(ctx, id, userID)
+	// Bookings are always made by their client
+	if err := recordBookingEvent(ctx, st, nil, booking, booking.ClientID, actionCreate, ""); err != nil {
+		return err
+	}
+	return scheduleReminders(ctx, st, booking)
 }
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+)
+
+var (
+	ErrInvalidTransition   = apperr.InvalidTransition("INVALID_TRANSITION", "invalid booking status transition")
+	ErrTransitionForbidden = apperr.Forbidden("FORBIDDEN", "not permitted to perform this booking action")
+)
+
+type BookingAction string
+
+const (
+	ActionUpdate     BookingAction = "update"
+	ActionConfirm    BookingAction = "confirm"
+	ActionDecline    BookingAction = "decline"
+	ActionStart      BookingAction = "start"
+	ActionComplete   BookingAction = "complete"
+	ActionCancel     BookingAction = "cancel"
+	ActionNoShow     BookingAction = "no_show"
+	ActionReschedule BookingAction = "reschedule"
+	// ActionExpire and ActionAwaitCompletion are only taken by the lifecycle scheduler
+	ActionExpire          BookingAction = "expire"
+	ActionAwaitCompletion BookingAction = "await_completion"
+)
+
+type Party string
+
+const (
+	PartyWorker Party = "worker"
+	PartyClient Party = "client"
+	// PartySystem is the service itself, acting with an empty actor id
+	PartySystem Party = "system"
+)
+
+type TransitionGuard func(b *models.Booking, now time.Time) error
+
+type Transition struct {
+	From    []string
+	To      string
+	Parties []Party
+	Guards  []TransitionGuard
+}
+
+// bookingTransitions is the single source of truth for booking status changes.
+// An action whose To is empty leaves the status unchanged.
+var bookingTransitions = map[BookingAction]Transition{
+	ActionUpdate: {
+		From:    []string{models.BookingStatusPending},
+		Parties: []Party{PartyWorker, PartyClient},
+	},
+	ActionReschedule: {
+		From:    []string{models.BookingStatusPending, models.BookingStatusConfirmed},
+		Parties: []Party{PartyWorker, PartyClient},
+	},
+	ActionConfirm: {
+		From:    []string{models.BookingStatusPending},
+		To:      models.BookingStatusConfirmed,
+		Parties: []Party{PartyWorker},
+	},
+	ActionDecline: {
+		From:    []string{models.BookingStatusPending},
+		To:      models.BookingStatusDeclined,
+		Parties: []Party{PartyWorker},
+	},
+	ActionExpire: {
+		From:    []string{models.BookingStatusPending},
+		To:      models.BookingStatusExpired,
+		Parties: []Party{PartySystem},
+	},
+	ActionStart: {
+		From:    []string{models.BookingStatusConfirmed},
+		To:      models.BookingStatusInProgress,
+		Parties: []Party{PartyWorker, PartyClient, PartySystem},
+		Guards:  []TransitionGuard{notBeforeStart},
+	},
+	ActionAwaitCompletion: {
+		From:    []string{models.BookingStatusInProgress},
+		To:      models.BookingStatusAwaitingCompletion,
+		Parties: []Party{PartySystem},
+		Guards:  []TransitionGuard{notBeforeEnd},
+	},
+	ActionComplete: {
+		From:    []string{models.BookingStatusConfirmed, models.BookingStatusInProgress, models.BookingStatusAwaitingCompletion},
+		To:      models.BookingStatusCompleted,
+		Parties: []Party{PartyWorker, PartyClient, PartySystem},
+		Guards:  []TransitionGuard{notBeforeStart},
+	},
+	// A session can still be called off once it has started, as it always could
+	ActionCancel: {
+		From:    []string{models.BookingStatusPending, models.BookingStatusConfirmed, models.BookingStatusInProgress},
+		To:      models.BookingStatusCancelled,
+		Parties: []Party{PartyWorker, PartyClient},
+	},
+	ActionNoShow: {
+		From:    []string{models.BookingStatusConfirmed, models.BookingStatusInProgress, models.BookingStatusAwaitingCompletion},
+		To:      models.BookingStatusNoShow,
+		Parties: []Party{PartyWorker, PartyClient},
+		Guards:  []TransitionGuard{notBeforeStart},
+	},
+}
+
+func notBeforeStart(b *models.Booking, now time.Time) error {
+	if now.Before(b.StartTime) {
+		return errors.New("booking has not started yet")
+	}
+	return nil
+}
+
+func notBeforeEnd(b *models.Booking, now time.Time) error {
+	if now.Before(b.EndTime) {
+		return errors.New("booking has not ended yet")
+	}
+	return nil
+}
+
+type BookingStateMachine struct {
+	transitions map[BookingAction]Transition
+	now         func() time.Time
+}
+
+func NewBookingStateMachine() *BookingStateMachine {
+	return &BookingStateMachine{transitions: bookingTransitions, now: time.Now}
+}
+
+// Apply validates the action for the given actor and returns the resulting status. An empty
+// actorID is the system.
+func (m *BookingStateMachine) Apply(b *models.Booking, action BookingAction, actorID string) (string, error) {
+	t, ok := m.transitions[action]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown action %q", ErrInvalidTransition, action)
+	}
+
+	party, ok := partyOf(b, actorID)
+	if !ok || !slices.Contains(t.Parties, party) {
+		return "", fmt.Errorf("%w: %s cannot %s this booking", ErrTransitionForbidden, partyLabel(party, ok), action)
+	}
+
+	if !slices.Contains(t.From, b.Status) {
+		return "", fmt.Errorf("%w: cannot %s a %s booking", ErrInvalidTransition, action, b.Status)
+	}
+
+	now := m.now()
+	for _, guard := range t.Guards {
+		if err := guard(b, now); err != nil {
+			return "", fmt.Errorf("%w: cannot %s: %s", ErrInvalidTransition, action, err)
+		}
+	}
+
+	if t.To == "" {
+		return b.Status, nil
+	}
+	return t.To, nil
+}
+
+func partyOf(b *models.Booking, userID string) (Party, bool) {
+	switch userID {
+	case "":
+		return PartySystem, true
+	case b.WorkerID:
+		return PartyWorker, true
+	case b.ClientID:
+		return PartyClient, true
+	}
+	return "", false
+}
+
+func partyLabel(p Party, ok bool) string {
+	if !ok {
+		return "non-participant"
+	}
+	return string(p)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"booking-service/internal/models"
+)
+
+func TestBookingStateMachine(t *testing.T) {
+	start := time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	before, during, after := start.Add(-time.Hour), start.Add(30*time.Minute), end.Add(time.Minute)
+
+	const system, stranger = "", "44444444-4444-4444-4444-444444444444"
+
+	tests := []struct {
+		action BookingAction
+		from   string
+		actor  string
+		now    time.Time
+		want   string
+		err    error
+	}{
+		{action: ActionConfirm, from: models.BookingStatusPending, actor: testWorkerID, now: before, want: models.BookingStatusConfirmed},
+		{action: ActionConfirm, from: models.BookingStatusPending, actor: testClientID, now: before, err: ErrTransitionForbidden},
+		{action: ActionConfirm, from: models.BookingStatusConfirmed, actor: testWorkerID, now: before, err: ErrInvalidTransition},
+		{action: ActionDecline, from: models.BookingStatusPending, actor: testWorkerID, now: before, want: models.BookingStatusDeclined},
+		{action: ActionDecline, from: models.BookingStatusConfirmed, actor: testWorkerID, now: before, err: ErrInvalidTransition},
+		{action: ActionExpire, from: models.BookingStatusPending, actor: system, now: before, want: models.BookingStatusExpired},
+		{action: ActionExpire, from: models.BookingStatusPending, actor: testWorkerID, now: before, err: ErrTransitionForbidden},
+		{action: ActionStart, from: models.BookingStatusConfirmed, actor: testClientID, now: during, want: models.BookingStatusInProgress},
+		{action: ActionStart, from: models.BookingStatusConfirmed, actor: system, now: start, want: models.BookingStatusInProgress},
+		{action: ActionStart, from: models.BookingStatusConfirmed, actor: testWorkerID, now: before, err: ErrInvalidTransition},
+		{action: ActionStart, from: models.BookingStatusPending, actor: testWorkerID, now: during, err: ErrInvalidTransition},
+		{action: ActionAwaitCompletion, from: models.BookingStatusInProgress, actor: system, now: after, want: models.BookingStatusAwaitingCompletion},
+		{action: ActionAwaitCompletion, from: models.BookingStatusInProgress, actor: system, now: during, err: ErrInvalidTransition},
+		{action: ActionAwaitCompletion, from: models.BookingStatusInProgress, actor: testWorkerID, now: after, err: ErrTransitionForbidden},
+		{action: ActionComplete, from: models.BookingStatusConfirmed, actor: testWorkerID, now: during, want: models.BookingStatusCompleted},
+		{action: ActionComplete, from: models.BookingStatusAwaitingCompletion, actor: system, now: after, want: models.BookingStatusCompleted},
+		{action: ActionComplete, from: models.BookingStatusConfirmed, actor: testClientID, now: before, err: ErrInvalidTransition},
+		{action: ActionComplete, from: models.BookingStatusCompleted, actor: testClientID, now: after, err: ErrInvalidTransition},
+		{action: ActionCancel, from: models.BookingStatusPending, actor: testClientID, now: before, want: models.BookingStatusCancelled},
+		{action: ActionCancel, from: models.BookingStatusConfirmed, actor: testWorkerID, now: before, want: models.BookingStatusCancelled},
+		{action: ActionCancel, from: models.BookingStatusInProgress, actor: testClientID, now: during, want: models.BookingStatusCancelled},
+		{action: ActionCancel, from: models.BookingStatusAwaitingCompletion, actor: testClientID, now: after, err: ErrInvalidTransition},
+		{action: ActionCancel, from: models.BookingStatusCompleted, actor: testClientID, now: after, err: ErrInvalidTransition},
+		{action: ActionCancel, from: models.BookingStatusPending, actor: system, now: before, err: ErrTransitionForbidden},
+		{action: ActionCancel, from: models.BookingStatusPending, actor: stranger, now: before, err: ErrTransitionForbidden},
+		{action: ActionNoShow, from: models.BookingStatusAwaitingCompletion, actor: testWorkerID, now: after, want: models.BookingStatusNoShow},
+		{action: ActionNoShow, from: models.BookingStatusConfirmed, actor: testClientID, now: before, err: ErrInvalidTransition},
+		{action: ActionUpdate, from: models.BookingStatusPending, actor: testClientID, now: before, want: models.BookingStatusPending},
+		{action: ActionUpdate, from: models.BookingStatusConfirmed, actor: testClientID, now: before, err: ErrInvalidTransition},
+		{action: ActionReschedule, from: models.BookingStatusConfirmed, actor: testWorkerID, now: before, want: models.BookingStatusConfirmed},
+		{action: ActionReschedule, from: models.BookingStatusInProgress, actor: testWorkerID, now: during, err: ErrInvalidTransition},
+		{action: "teleport", from: models.BookingStatusPending, actor: testWorkerID, now: before, err: ErrInvalidTransition},
+	}
+	for _, tt := range tests {
+		m := NewBookingStateMachine()
+		m.now = func() time.Time { return tt.now }
+		b := &models.Booking{WorkerID: testWorkerID, ClientID: testClientID, Status: tt.from, StartTime: start, EndTime: end}
+
+		got, err := m.Apply(b, tt.action, tt.actor)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s a %s booking as %q: error = %v, want %v", tt.action, tt.from, tt.actor, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s a %s booking as %q = %s, %v, want %s", tt.action, tt.from, tt.actor, got, err, tt.want)
+		}
+	}
+}
+
+// Every status a participant can move a booking into must reach other services
+func TestTransitionEvents(t *testing.T) {
+	silent := map[BookingAction]bool{ActionUpdate: true, ActionReschedule: true, ActionStart: true, ActionAwaitCompletion: true, ActionCancel: true}
+	for action := range bookingTransitions {
+		if _, ok := transitionEvents[action]; !ok && !silent[action] {
+			t.Errorf("action %s publishes no event", action)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+var (
+	ErrInvalidTimeRange    = apperr.Validation("INVALID_TIME_RANGE", "end time must be after start time")
+	ErrOutsideAvailability = apperr.Validation("OUTSIDE_AVAILABILITY", "requested time is outside the worker's availability")
+	ErrSlotUnavailable     = apperr.Conflict("SLOT_UNAVAILABLE", "requested time slot is no longer available")
+	ErrSchedulingRule      = apperr.Validation("SCHEDULING_RULE_VIOLATION", "booking violates the worker's scheduling rules")
+)
+
+// DefaultSchedulingRules applies to workers who have not configured their own.
+// A MaxAdvanceDays of zero means there is no booking horizon.
+func DefaultSchedulingRules() models.SchedulingRules {
+	return models.SchedulingRules{
+		MinNoticeMinutes:   60,
+		MaxAdvanceDays:     90,
+		SlotStepMinutes:    30,
+		MinDurationMinutes: 30,
+		MaxDurationMinutes: 480,
+	}
+}
+
+type workerSchedule struct {
+	Availability []models.AvailabilitySlot
+	Location     *time.Location
+	Rules        models.SchedulingRules
+}
+
+type timeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (r timeRange) overlaps(start, end time.Time) bool {
+	return start.Before(r.End) && end.After(r.Start)
+}
+
+func loadWorkerSchedule(ctx context.Context, st store.Store, workerID string) (*workerSchedule, error) {
+	settings, err := st.Availability().Get(ctx, workerID)
+	if err != nil {
+		return nil, err
+	}
+	return scheduleFromSettings(settings), nil
+}
+
+// scheduleFromSettings resolves the worker's calendar settings, filling in the defaults
+func scheduleFromSettings(settings *store.WorkerSettings) *workerSchedule {
+	schedule := &workerSchedule{Availability: []models.AvailabilitySlot{}, Location: workerLocation(settings), Rules: DefaultSchedulingRules()}
+
+	if settings.Availability != nil {
+		schedule.Availability = settings.Availability
+	}
+
+	if settings.SchedulingRules != nil {
+		// Fields the worker never set keep their defaults
+		rules := schedule.Rules
+		if err := json.Unmarshal(settings.SchedulingRules, &rules); err == nil {
+			schedule.Rules = rules
+		}
+	}
+
+	return schedule
+}
+
+// workerLocation is the worker's timezone. Unknown zone names fall back to UTC rather than making
+// the worker unbookable.
+func workerLocation(settings *store.WorkerSettings) *time.Location {
+	if settings.Timezone != "" {
+		if loc, err := time.LoadLocation(settings.Timezone); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+func loadAvailability(ctx context.Context, st store.Store, workerID string) ([]models.AvailabilitySlot, error) {
+	schedule, err := loadWorkerSchedule(ctx, st, workerID)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.Availability, nil
+}
+
+// windowsOn resolves the recurring availability for a calendar date in the worker's timezone
+func (ws *workerSchedule) windowsOn(year int, month time.Month, day int) []timeRange {
+	weekday := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday()
+
+	var windows []timeRange
+	for _, avail := range ws.Availability {
+		if avail.DayOfWeek != int(weekday) {
+			continue
+		}
+
+		startHour, startMin := parseTimeString(avail.StartTime)
+		endHour, endMin := parseTimeString(avail.EndTime)
+
+		window := timeRange{
+			Start: wallClock(year, month, day, startHour, startMin, ws.Location, false),
+			End:   wallClock(year, month, day, endHour, endMin, ws.Location, true),
+		}
+		if window.End.After(window.Start) {
+			windows = append(windows, window)
+		}
+	}
+
+	return windows
+}
+
+// slotsOn generates free slots of the given duration for a calendar date, honouring the
+// worker's slot step, buffers, minimum notice and booking horizon
+func (ws *workerSchedule) slotsOn(year int, month time.Month, day int, duration time.Duration, busy []timeRange, now time.Time, displayLoc *time.Location) []models.TimeSlot {
+	slots := make([]models.TimeSlot, 0)
+	step := time.Duration(ws.Rules.SlotStepMinutes) * time.Minute
+
+	for _, window := range ws.windowsOn(year, month, day) {
+		// Step in absolute time so DST transitions inside a window are handled naturally
+		for current := window.Start; !current.Add(duration).After(window.End); current = current.Add(step) {
+			proposedEnd := current.Add(duration)
+
+			if checkLeadTime(ws.Rules, current, now) != nil {
+				continue
+			}
+
+			if !conflicts(ws.Rules, busy, current, proposedEnd) {
+				slots = append(slots, models.TimeSlot{
+					StartTime:   current.In(displayLoc),
+					EndTime:     proposedEnd.In(displayLoc),
+					IsAvailable: true,
+				})
+			}
+		}
+	}
+
+	return slots
+}
+
+// wholeMinutes is how long a booking from start to end lasts in whole minutes. A trailing partial
+// minute is neither recorded nor charged.
+func wholeMinutes(start, end time.Time) int {
+	return int(end.Sub(start) / time.Minute)
+}
+
+func checkDuration(rules models.SchedulingRules, duration time.Duration) error {
+	minutes := int(duration / time.Minute)
+	if minutes < rules.MinDurationMinutes || minutes > rules.MaxDurationMinutes {
+		return fmt.Errorf("%w: duration must be between %d and %d minutes", ErrSchedulingRule, rules.MinDurationMinutes, rules.MaxDurationMinutes)
+	}
+	return nil
+}
+
+func checkLeadTime(rules models.SchedulingRules, start, now time.Time) error {
+	if start.Before(now.Add(time.Duration(rules.MinNoticeMinutes) * time.Minute)) {
+		return fmt.Errorf("%w: bookings require at least %d minutes notice", ErrSchedulingRule, rules.MinNoticeMinutes)
+	}
+	if rules.MaxAdvanceDays > 0 && start.After(now.AddDate(0, 0, rules.MaxAdvanceDays)) {
+		return fmt.Errorf("%w: bookings can be made at most %d days in advance", ErrSchedulingRule, rules.MaxAdvanceDays)
+	}
+	return nil
+}
+
+// conflicts reports whether [start, end) plus the worker's buffers overlaps any busy range.
+// Booking and hold ranges returned by loadBusyRanges already carry their own buffers.
+func conflicts(rules models.SchedulingRules, busy []timeRange, start, end time.Time) bool {
+	paddedStart := start.Add(-time.Duration(rules.BufferBeforeMinutes) * time.Minute)
+	paddedEnd := end.Add(time.Duration(rules.BufferAfterMinutes) * time.Minute)
+
+	for _, booked := range busy {
+		if booked.overlaps(paddedStart, paddedEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+// dayBounds returns the instants at which the calendar date starts and ends in loc
+func dayBounds(year int, month time.Month, day int, loc *time.Location) timeRange {
+	return timeRange{
+		Start: wallClock(year, month, day, 0, 0, loc, false),
+		End:   wallClock(year, month, day+1, 0, 0, loc, false),
+	}
+}
+
+// wallClock resolves a local wall-clock time to an instant. A time skipped by a DST gap
+// resolves to the end of the gap; a time repeated by a DST overlap resolves to its earlier
+// occurrence, or the later one when latest is set.
+func wallClock(year int, month time.Month, day, hour, min int, loc *time.Location, latest bool) time.Time {
+	// Normalise first so values such as 24:00 roll over to the next day
+	want := time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	t := time.Date(want.Year(), want.Month(), want.Day(), want.Hour(), want.Minute(), 0, 0, loc)
+
+	got := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+	if !got.Equal(want) {
+		start, end := t.ZoneBounds()
+		if got.After(want) {
+			return start
+		}
+		return end
+	}
+
+	var neighbours []time.Time
+	start, end := t.ZoneBounds()
+	if !start.IsZero() {
+		neighbours = append(neighbours, start.Add(-time.Nanosecond))
+	}
+	if !end.IsZero() {
+		neighbours = append(neighbours, end)
+	}
+
+	candidates := []time.Time{t}
+	for _, neighbour := range neighbours {
+		_, offset := neighbour.In(loc).Zone()
+		alt := want.Add(-time.Duration(offset) * time.Second).In(loc)
+		if !alt.Equal(t) && time.Date(alt.Year(), alt.Month(), alt.Day(), alt.Hour(), alt.Minute(), 0, 0, time.UTC).Equal(want) {
+			candidates = append(candidates, alt)
+		}
+	}
+
+	result := candidates[0]
+	for _, c := range candidates[1:] {
+		if (latest && c.After(result)) || (!latest && c.Before(result)) {
+			result = c
+		}
+	}
+	return result
+}
+
+// fitsAvailability reports whether [start, end) lies inside a single recurring availability window
+func fitsAvailability(schedule *workerSchedule, start, end time.Time) bool {
+	local := start.In(schedule.Location)
+	for _, window := range schedule.windowsOn(local.Year(), local.Month(), local.Day()) {
+		if !start.Before(window.Start) && !end.After(window.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// slotRequest describes a time range someone wants to occupy on a worker's calendar
+type slotRequest struct {
+	WorkerID string
+	Start    time.Time
+	End      time.Time
+	// ExcludeBookingID is the booking being moved, which must not conflict with itself
+	ExcludeBookingID string
+	// HolderID is the client the range is for; their own holds don't count as busy
+	HolderID string
+}
+
+// ensureBookable checks the range against the worker's scheduling rules, recurring availability,
+// blocked slots, other clients' holds and non-cancelled bookings. Callers running inside a
+// transaction should hold the worker lock.
+func ensureBookable(ctx context.Context, st store.Store, slot slotRequest, now time.Time) error {
+	start, end := slot.Start, slot.End
+	if !end.After(start) {
+		return ErrInvalidTimeRange
+	}
+
+	schedule, err := loadWorkerSchedule(ctx, st, slot.WorkerID)
+	if err != nil {
+		return err
+	}
+
+	if err := checkDuration(schedule.Rules, end.Sub(start)); err != nil {
+		return err
+	}
+
+	if err := checkLeadTime(schedule.Rules, start, now); err != nil {
+		return err
+	}
+
+	if !fitsAvailability(schedule, start, end) {
+		return ErrOutsideAvailability
+	}
+
+	busy, err := loadBusyRanges(ctx, st, slot, schedule.Rules, now)
+	if err != nil {
+		return err
+	}
+
+	if conflicts(schedule.Rules, busy, start, end) {
+		return ErrSlotUnavailable
+	}
+
+	return nil
+}
+
+// loadBusyRanges returns non-cancelled bookings and other clients' unexpired holds, padded by the
+// worker's buffers, and blocked slots that could conflict with a booking in the slot's range
+func loadBusyRanges(ctx context.Context, st store.Store, slot slotRequest, rules models.SchedulingRules, now time.Time) ([]timeRange, error) {
+	workerID, from, to := slot.WorkerID, slot.Start, slot.End
+	before := time.Duration(rules.BufferBeforeMinutes) * time.Minute
+	after := time.Duration(rules.BufferAfterMinutes) * time.Minute
+	margin := before + after
+
+	bookings, err := st.Bookings().ListActive(ctx, workerID, from.Add(-margin), to.Add(margin))
+	if err != nil {
+		return nil, err
+	}
+
+	blocked, err := st.BlockedSlots().ListOverlapping(ctx, workerID, from.Add(-margin), to.Add(margin))
+	if err != nil {
+		return nil, err
+	}
+
+	holds, err := st.Holds().ListActive(ctx, workerID, from.Add(-margin), to.Add(margin), now)
+	if err != nil {
+		return nil, err
+	}
+
+	busy := make([]timeRange, 0, len(bookings)+len(blocked)+len(holds))
+	for _, b := range bookings {
+		if b.ID == slot.ExcludeBookingID {
+			continue
+		}
+		busy = append(busy, timeRange{Start: b.StartTime.Add(-before), End: b.EndTime.Add(after)})
+	}
+	for _, hold := range holds {
+		if slot.HolderID != "" && hold.ClientID == slot.HolderID {
+			continue
+		}
+		busy = append(busy, timeRange{Start: hold.StartTime.Add(-before), End: hold.EndTime.Add(after)})
+	}
+	for _, blockedSlot := range blocked {
+		busy = append(busy, timeRange{Start: blockedSlot.StartTime, End: blockedSlot.EndTime})
+	}
+
+	return busy, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/ical"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+var ErrCalendarFeedNotFound = apperr.NotFound("NOT_FOUND", "calendar feed not found")
+
+// CreateCalendarFeed issues a new feed token for the user, replacing any previous one. Only a
+// hash of the token is stored, so the plain token is returned exactly once.
+func (s *BookingService) CreateCalendarFeed(ctx context.Context, userID string, role string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	err := s.store.CalendarFeeds().Put(ctx, &store.CalendarFeed{UserID: userID, TokenHash: hashFeedToken(token), Role: role, CreatedAt: time.Now()})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *BookingService) RevokeCalendarFeed(ctx context.Context, userID string) error {
+	return s.store.CalendarFeeds().Delete(ctx, userID)
+}
+
+// GetCalendarFeed renders the bookings of the feed's owner, the same list GetUserBookings returns
+func (s *BookingService) GetCalendarFeed(ctx context.Context, token string) ([]byte, error) {
+	feed, err := s.store.CalendarFeeds().GetByTokenHash(ctx, hashFeedToken(token))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrCalendarFeedNotFound
+		}
+		return nil, err
+	}
+
+	bookings, err := s.GetUserBookings(ctx, feed.UserID, feed.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ical.Calendar{Name: "Tulifo bookings", Events: make([]ical.Event, 0, len(bookings))}
+	for _, b := range bookings {
+		cal.Events = append(cal.Events, bookingEvent(b, feed.UserID))
+	}
+
+	return cal.Encode(), nil
+}
+
+func (s *BookingService) GetBookingICS(ctx context.Context, id string, userID string) ([]byte, error) {
+	booking, err := s.GetBookingByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := &ical.Calendar{Events: []ical.Event{bookingEvent(booking, userID)}}
+	return cal.Encode(), nil
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+var icalStatus = map[string]string{
+	models.BookingStatusPending:            ical.StatusTentative,
+	models.BookingStatusConfirmed:          ical.StatusConfirmed,
+	models.BookingStatusInProgress:         ical.StatusConfirmed,
+	models.BookingStatusCompleted:          ical.StatusConfirmed,
+	models.BookingStatusNoShow:             ical.StatusConfirmed,
+	models.BookingStatusCancelled:          ical.StatusCancelled,
+	models.BookingStatusDeclined:           ical.StatusCancelled,
+	models.BookingStatusExpired:            ical.StatusCancelled,
+	models.BookingStatusAwaitingCompletion: ical.StatusConfirmed,
+}
+
+// bookingEvent describes the booking from the viewer's side, naming the other party
+func bookingEvent(b *models.Booking, viewerID string) ical.Event {
+	counterparty := b.Client
+	if b.ClientID == viewerID {
+		counterparty = b.Worker
+	}
+
+	var details []string
+	if counterparty != nil {
+		details = append(details, fmt.Sprintf("With: %s %s", counterparty.FirstName, counterparty.LastName))
+	}
+	if b.Description != "" {
+		details = append(details, b.Description)
+	}
+
+	event := ical.Event{
+		UID:          b.ID + "@bookings.tulifo",
+		Summary:      b.Title,
+		Status:       icalStatus[b.Status],
+		Start:        b.StartTime,
+		End:          b.EndTime,
+		Created:      b.CreatedAt,
+		LastModified: b.UpdatedAt,
+	}
+	if b.MeetingURL != nil && *b.MeetingURL != "" {
+		details = append(details, "Join: "+*b.MeetingURL)
+		event.Location = *b.MeetingURL
+		event.URL = *b.MeetingURL
+	}
+	event.Description = strings.Join(details, "\n")
+
+	return event
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"booking-service/internal/models"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestWallClock(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	newYork := mustLoadLocation(t, "America/New_York")
+	utc := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name   string
+		month  time.Month
+		day    int
+		hour   int
+		min    int
+		loc    *time.Location
+		latest bool
+		want   time.Time
+	}{
+		{name: "summer time", month: time.July, day: 1, hour: 10, loc: berlin, want: utc(time.July, 1, 8, 0)},
+		{name: "winter time", month: time.January, day: 15, hour: 10, loc: berlin, want: utc(time.January, 15, 9, 0)},
+		{name: "24:00 is midnight of the next day", month: time.March, day: 28, hour: 24, loc: berlin, want: utc(time.March, 28, 23, 0)},
+		{name: "gap resolves to its end", month: time.March, day: 29, hour: 2, min: 30, loc: berlin, want: utc(time.March, 29, 1, 0)},
+		{name: "gap resolves to its end when latest", month: time.March, day: 29, hour: 2, min: 30, loc: berlin, latest: true, want: utc(time.March, 29, 1, 0)},
+		{name: "overlap, earlier occurrence", month: time.October, day: 25, hour: 2, min: 30, loc: berlin, want: utc(time.October, 25, 0, 30)},
+		{name: "overlap, later occurrence", month: time.October, day: 25, hour: 2, min: 30, loc: berlin, latest: true, want: utc(time.October, 25, 1, 30)},
+		{name: "gap west of UTC", month: time.March, day: 8, hour: 2, min: 30, loc: newYork, want: utc(time.March, 8, 7, 0)},
+		{name: "overlap west of UTC, earlier", month: time.November, day: 1, hour: 1, min: 30, loc: newYork, want: utc(time.November, 1, 5, 30)},
+		{name: "overlap west of UTC, later", month: time.November, day: 1, hour: 1, min: 30, loc: newYork, latest: true, want: utc(time.November, 1, 6, 30)},
+		{name: "UTC", month: time.March, day: 29, hour: 2, min: 30, loc: time.UTC, latest: true, want: utc(time.March, 29, 2, 30)},
+	}
+	for _, tt := range tests {
+		got := wallClock(2026, tt.month, tt.day, tt.hour, tt.min, tt.loc, tt.latest)
+		if !got.Equal(tt.want) {
+			t.Errorf("%s: wallClock(%s %d %02d:%02d) = %s, want %s", tt.name, tt.month, tt.day, tt.hour, tt.min, got.UTC(), tt.want)
+		}
+	}
+}
+
+// A day-long window is as long as the day actually is in the worker's timezone
+func TestWindowsOnDSTDays(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		month time.Month
+		day   int
+		want  time.Duration
+	}{
+		{month: time.March, day: 29, want: 23 * time.Hour},
+		{month: time.October, day: 25, want: 25 * time.Hour},
+		{month: time.July, day: 5, want: 24 * time.Hour},
+	}
+	for _, tt := range tests {
+		weekday := time.Date(2026, tt.month, tt.day, 0, 0, 0, 0, time.UTC).Weekday()
+		ws := &workerSchedule{
+			Availability: []models.AvailabilitySlot{{DayOfWeek: int(weekday), StartTime: "00:00", EndTime: "24:00", IsRecurring: true}},
+			Location:     berlin,
+		}
+
+		windows := ws.windowsOn(2026, tt.month, tt.day)
+		if len(windows) != 1 {
+			t.Errorf("%s %d: %d windows, want 1", tt.month, tt.day, len(windows))
+			continue
+		}
+		if got := windows[0].End.Sub(windows[0].Start); got != tt.want {
+			t.Errorf("%s %d: window lasts %s, want %s", tt.month, tt.day, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/events"
+	"booking-service/internal/models"
+	"booking-service/internal/money"
+	"booking-service/internal/store"
+)
+
+var ErrInvalidCancellationPolicy = apperr.Validation("VALIDATION_ERROR", "invalid cancellation policy")
+
+// Preset policies. Tiers are ordered from the longest notice down; a client cancelling with less
+// notice than the last tier gets nothing back.
+var cancellationPresets = map[string]models.CancellationPolicy{
+	models.CancellationPolicyFlexible: {
+		Name:  models.CancellationPolicyFlexible,
+		Tiers: []models.CancellationTier{{HoursBeforeStart: 24, RefundPercent: 100}, {HoursBeforeStart: 0, RefundPercent: 50}},
+	},
+	models.CancellationPolicyModerate: {
+		Name:                 models.CancellationPolicyModerate,
+		Tiers:                []models.CancellationTier{{HoursBeforeStart: 72, RefundPercent: 100}, {HoursBeforeStart: 24, RefundPercent: 50}},
+		WorkerPenaltyPercent: 10,
+	},
+	models.CancellationPolicyStrict: {
+		Name:                 models.CancellationPolicyStrict,
+		Tiers:                []models.CancellationTier{{HoursBeforeStart: 168, RefundPercent: 100}, {HoursBeforeStart: 48, RefundPercent: 50}},
+		WorkerPenaltyPercent: 20,
+	},
+}
+
+func DefaultCancellationPolicy() models.CancellationPolicy {
+	return cancellationPresets[models.CancellationPolicyFlexible]
+}
+
+// NormalizeCancellationPolicy expands a preset name into its tiers and checks custom tiers
+func NormalizeCancellationPolicy(p *models.CancellationPolicy) error {
+	if preset, ok := cancellationPresets[p.Name]; ok {
+		*p = preset
+		return nil
+	}
+
+	if len(p.Tiers) == 0 {
+		return fmt.Errorf("%w: a custom policy needs at least one tier", ErrInvalidCancellationPolicy)
+	}
+
+	slices.SortFunc(p.Tiers, func(a, b models.CancellationTier) int {
+		return b.HoursBeforeStart - a.HoursBeforeStart
+	})
+	for i := 1; i < len(p.Tiers); i++ {
+		if p.Tiers[i].HoursBeforeStart == p.Tiers[i-1].HoursBeforeStart {
+			return fmt.Errorf("%w: duplicate tier at %d hours", ErrInvalidCancellationPolicy, p.Tiers[i].HoursBeforeStart)
+		}
+		if p.Tiers[i].RefundPercent > p.Tiers[i-1].RefundPercent {
+			return fmt.Errorf("%w: refunds must not grow as the start time gets closer", ErrInvalidCancellationPolicy)
+		}
+	}
+	return nil
+}
+
+// refundPercent picks the first tier whose notice period the cancellation still meets
+func refundPercent(policy models.CancellationPolicy, hoursBefore float64) int {
+	for _, tier := range policy.Tiers {
+		if hoursBefore >= float64(tier.HoursBeforeStart) {
+			return tier.RefundPercent
+		}
+	}
+	return 0
+}
+
+// cancellationOutcome works out who pays what when actorID cancels the booking at now. The client
+// is refunded by the policy tiers and the rest goes to the worker. A worker who cancels refunds
+// the client in full and, inside the window where a client would have lost money, owes
+// WorkerPenaltyPercent of the total. Pending bookings were never confirmed, so they cost nothing,
+// and staff cancelling on behalf of the platform refund the client in full at no cost to the worker.
+func cancellationOutcome(b *models.Booking, actorID string, now time.Time) (*models.CancellationOutcome, error) {
+	policy := DefaultCancellationPolicy()
+	if b.CancellationPolicy != nil {
+		policy = *b.CancellationPolicy
+	}
+
+	hoursBefore := b.StartTime.Sub(now).Hours()
+	outcome := &models.CancellationOutcome{
+		Policy:           policy.Name,
+		CancelledBy:      string(PartyClient),
+		CancelledAt:      now,
+		HoursBeforeStart: math.Round(hoursBefore*100) / 100,
+		RefundPercent:    100,
+		Currency:         b.Currency,
+	}
+	if party, ok := partyOf(b, actorID); !ok {
+		outcome.CancelledBy = models.ActorRoleStaff
+	} else if party == PartyWorker {
+		outcome.CancelledBy = string(PartyWorker)
+	}
+
+	tierPercent := refundPercent(policy, hoursBefore)
+	switch {
+	case b.Status == models.BookingStatusPending, outcome.CancelledBy == models.ActorRoleStaff:
+	case outcome.CancelledBy == string(PartyWorker):
+		if tierPercent < 100 {
+			penalty, err := b.TotalAmount.Percent(policy.WorkerPenaltyPercent)
+			if err != nil {
+				return nil, err
+			}
+			outcome.WorkerPenalty = roundMoney(penalty, b.Currency)
+		}
+	default:
+		outcome.RefundPercent = tierPercent
+	}
+
+	// The penalty is whatever isn't refunded, so the two always add up to the total
+	refund, err := b.TotalAmount.Percent(outcome.RefundPercent)
+	if err != nil {
+		return nil, err
+	}
+	outcome.ClientRefund = roundMoney(refund, b.Currency)
+	outcome.ClientPenalty = b.TotalAmount.Sub(outcome.ClientRefund)
+	return outcome, nil
+}
+
+// roundMoney rounds to the currency's minor unit. Bookings only ever hold known currencies, so an
+// unknown one leaves the amount as is.
+func roundMoney(amount money.Amount, currency string) money.Amount {
+	if rounded, err := amount.Round(currency); err == nil {
+		return rounded
+	}
+	return amount
+}
+
+// cancelBooking moves the booking to status and stores what the cancellation costs each party
+func cancelBooking(ctx context.Context, st store.Store, b *models.Booking, status string, actorID string, reason string, now time.Time) error {
+	outcome, err := cancellationOutcome(b, actorID, now)
+	if err != nil {
+		return err
+	}
+	b.Cancellation = outcome
+
+	if err := setBookingStatus(ctx, st, b, status, actorID, ActionCancel, reason); err != nil {
+		return err
+	}
+
+	return enqueueBookingEvent(ctx, st, events.BookingCancelled, b.ID)
+}
+
+// PreviewCancellation reports what cancelling would cost without changing the booking
+func (s *BookingService) PreviewCancellation(ctx context.Context, id string, userID string) (*models.CancellationOutcome, error) {
+	booking, err := s.GetBookingByID(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.states.Apply(booking, ActionCancel, userID); err != nil {
+		return nil, err
+	}
+
+	return cancellationOutcome(booking, userID, time.Now())
+}
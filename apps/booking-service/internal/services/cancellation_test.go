@@ -0,0 +1,94 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/money"
+)
+
+func TestNormalizeCancellationPolicy(t *testing.T) {
+	tier := func(hours, percent int) models.CancellationTier {
+		return models.CancellationTier{HoursBeforeStart: hours, RefundPercent: percent}
+	}
+
+	tests := []struct {
+		name   string
+		policy models.CancellationPolicy
+		want   []models.CancellationTier
+		err    error
+	}{
+		{name: "preset", policy: models.CancellationPolicy{Name: models.CancellationPolicyStrict}, want: []models.CancellationTier{tier(168, 100), tier(48, 50)}},
+		{name: "preset tiers win", policy: models.CancellationPolicy{Name: models.CancellationPolicyFlexible, Tiers: []models.CancellationTier{tier(1, 10)}}, want: []models.CancellationTier{tier(24, 100), tier(0, 50)}},
+		{name: "custom tiers are sorted", policy: models.CancellationPolicy{Name: "custom", Tiers: []models.CancellationTier{tier(12, 25), tier(48, 100)}}, want: []models.CancellationTier{tier(48, 100), tier(12, 25)}},
+		{name: "no tiers", policy: models.CancellationPolicy{Name: "custom"}, err: ErrInvalidCancellationPolicy},
+		{name: "duplicate tiers", policy: models.CancellationPolicy{Name: "custom", Tiers: []models.CancellationTier{tier(24, 100), tier(24, 50)}}, err: ErrInvalidCancellationPolicy},
+		{name: "refund grows", policy: models.CancellationPolicy{Name: "custom", Tiers: []models.CancellationTier{tier(48, 50), tier(24, 100)}}, err: ErrInvalidCancellationPolicy},
+	}
+	for _, tt := range tests {
+		err := NormalizeCancellationPolicy(&tt.policy)
+		if tt.err != nil {
+			if !errors.Is(err, tt.err) {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(tt.policy.Tiers, tt.want) {
+			t.Errorf("%s: tiers = %v, %v, want %v", tt.name, tt.policy.Tiers, err, tt.want)
+		}
+	}
+}
+
+func TestCancellationOutcome(t *testing.T) {
+	now := time.Date(2026, 10, 20, 10, 0, 0, 0, time.UTC)
+	moderate := cancellationPresets[models.CancellationPolicyModerate]
+
+	tests := []struct {
+		name        string
+		policy      *models.CancellationPolicy
+		status      string
+		actor       string
+		notice      time.Duration
+		cancelledBy string
+		refund      int
+		// clientPenalty and workerPenalty are amounts out of a 100.00 total
+		clientPenalty string
+		workerPenalty string
+	}{
+		{name: "client, full refund tier", policy: &moderate, status: models.BookingStatusConfirmed, actor: testClientID, notice: 100 * time.Hour, cancelledBy: "client", refund: 100, clientPenalty: "0", workerPenalty: "0"},
+		{name: "client, half refund tier", policy: &moderate, status: models.BookingStatusConfirmed, actor: testClientID, notice: 48 * time.Hour, cancelledBy: "client", refund: 50, clientPenalty: "50", workerPenalty: "0"},
+		{name: "client, past every tier", policy: &moderate, status: models.BookingStatusConfirmed, actor: testClientID, notice: 12 * time.Hour, cancelledBy: "client", refund: 0, clientPenalty: "100", workerPenalty: "0"},
+		{name: "client, on a tier boundary", policy: &moderate, status: models.BookingStatusConfirmed, actor: testClientID, notice: 72 * time.Hour, cancelledBy: "client", refund: 100, clientPenalty: "0", workerPenalty: "0"},
+		{name: "client, pending booking", policy: &moderate, status: models.BookingStatusPending, actor: testClientID, notice: 12 * time.Hour, cancelledBy: "client", refund: 100, clientPenalty: "0", workerPenalty: "0"},
+		{name: "client, default policy", status: models.BookingStatusConfirmed, actor: testClientID, notice: 2 * time.Hour, cancelledBy: "client", refund: 50, clientPenalty: "50", workerPenalty: "0"},
+		{name: "worker, late", policy: &moderate, status: models.BookingStatusConfirmed, actor: testWorkerID, notice: 12 * time.Hour, cancelledBy: "worker", refund: 100, clientPenalty: "0", workerPenalty: "10"},
+		{name: "worker, early", policy: &moderate, status: models.BookingStatusConfirmed, actor: testWorkerID, notice: 100 * time.Hour, cancelledBy: "worker", refund: 100, clientPenalty: "0", workerPenalty: "0"},
+		{name: "worker, pending booking", policy: &moderate, status: models.BookingStatusPending, actor: testWorkerID, notice: 12 * time.Hour, cancelledBy: "worker", refund: 100, clientPenalty: "0", workerPenalty: "0"},
+		{name: "staff", policy: &moderate, status: models.BookingStatusConfirmed, actor: testStaffID, notice: 12 * time.Hour, cancelledBy: models.ActorRoleStaff, refund: 100, clientPenalty: "0", workerPenalty: "0"},
+	}
+	for _, tt := range tests {
+		total := money.MustParse("100")
+		b := &models.Booking{
+			WorkerID: testWorkerID, ClientID: testClientID, Status: tt.status,
+			StartTime: now.Add(tt.notice), TotalAmount: total, Currency: "EUR", CancellationPolicy: tt.policy,
+		}
+
+		got, err := cancellationOutcome(b, tt.actor, now)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got.CancelledBy != tt.cancelledBy || got.RefundPercent != tt.refund {
+			t.Errorf("%s: cancelled by %s with %d%% refund, want %s with %d%%", tt.name, got.CancelledBy, got.RefundPercent, tt.cancelledBy, tt.refund)
+		}
+		if got.ClientPenalty.Cmp(money.MustParse(tt.clientPenalty)) != 0 || got.WorkerPenalty.Cmp(money.MustParse(tt.workerPenalty)) != 0 {
+			t.Errorf("%s: penalties = client %s, worker %s, want %s, %s", tt.name, got.ClientPenalty, got.WorkerPenalty, tt.clientPenalty, tt.workerPenalty)
+		}
+		if got.ClientRefund.Add(got.ClientPenalty).Cmp(total) != 0 {
+			t.Errorf("%s: refund %s and penalty %s do not add up to %s", tt.name, got.ClientRefund, got.ClientPenalty, total)
+		}
+	}
+}
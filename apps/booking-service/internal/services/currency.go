@@ -0,0 +1,24 @@
+package services
+
+import (
+	"fmt"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/money"
+	"booking-service/internal/store"
+)
+
+var ErrUnsupportedCurrency = apperr.Validation("UNSUPPORTED_CURRENCY", "worker prices in an unsupported currency")
+
+// settingsCurrency is the currency the worker prices in, which every booking with them uses
+func settingsCurrency(settings *store.WorkerSettings) (string, error) {
+	if settings.Currency == "" {
+		return money.DefaultCurrency, nil
+	}
+
+	currency, err := money.NormalizeCurrency(settings.Currency)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrUnsupportedCurrency, err)
+	}
+	return currency, nil
+}
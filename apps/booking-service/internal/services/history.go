@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// actionCreate is recorded in the history when a booking is made; it is not a state machine action
+const actionCreate BookingAction = "create"
+
+// recordBookingEvent appends what actorID did to the booking's history. before is the booking as
+// it was read, or nil when it was just created. An empty actorID records a system change.
+func recordBookingEvent(ctx context.Context, st store.Store, before, after *models.Booking, actorID string, action BookingAction, reason string) error {
+	event := &models.BookingEvent{
+		ID:        uuid.New().String(),
+		BookingID: after.ID,
+		ActorRole: actorRole(after, actorID),
+		Action:    string(action),
+		ToStatus:  after.Status,
+		CreatedAt: time.Now(),
+	}
+	if actorID != "" {
+		event.ActorID = &actorID
+	}
+	if before != nil {
+		event.FromStatus = &before.Status
+		event.Changes = bookingChanges(before, after)
+	}
+	if reason != "" {
+		event.Reason = &reason
+	}
+	return st.History().Append(ctx, event)
+}
+
+func actorRole(b *models.Booking, actorID string) string {
+	if actorID == "" {
+		return models.ActorRoleSystem
+	}
+	if party, ok := partyOf(b, actorID); ok {
+		return string(party)
+	}
+	// Only staff act on bookings they are not a party to
+	return models.ActorRoleStaff
+}
+
+// bookingChanges lists the details that differ between two versions of a booking. Status is
+// recorded on the event itself.
+func bookingChanges(before, after *models.Booking) map[string]models.FieldChange {
+	changes := map[string]models.FieldChange{}
+	diff := func(field string, from, to any, equal bool) {
+		if !equal {
+			changes[field] = models.FieldChange{From: from, To: to}
+		}
+	}
+
+	diff("title", before.Title, after.Title, before.Title == after.Title)
+	diff("description", before.Description, after.Description, before.Description == after.Description)
+	diff("notes", before.Notes, after.Notes, equalPtr(before.Notes, after.Notes))
+	diff("startTime", before.StartTime, after.StartTime, before.StartTime.Equal(after.StartTime))
+	diff("endTime", before.EndTime, after.EndTime, before.EndTime.Equal(after.EndTime))
+	diff("totalAmount", before.TotalAmount, after.TotalAmount, before.TotalAmount.Cmp(after.TotalAmount) == 0)
+	diff("seriesId", before.SeriesID, after.SeriesID, equalPtr(before.SeriesID, after.SeriesID))
+	diff("isRemote", before.IsRemote, after.IsRemote, before.IsRemote == after.IsRemote)
+	// Meeting links let anyone holding them in, so the history only shows that the link changed
+	diff("meetingUrl", redactedURL(before.MeetingURL), redactedURL(after.MeetingURL), equalPtr(before.MeetingURL, after.MeetingURL))
+
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+// redactedMeetingURL stands in for a meeting link in the history
+const redactedMeetingURL = "[redacted]"
+
+func redactedURL(url *string) *string {
+	if url == nil {
+		return nil
+	}
+	redacted := redactedMeetingURL
+	return &redacted
+}
+
+func equalPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// GetBookingHistory returns the booking's history, oldest first, to its worker and client
+func (s *BookingService) GetBookingHistory(ctx context.Context, id string, userID string) ([]*models.BookingEvent, error) {
+	if _, err := getBooking(ctx, s.store, id, userID); err != nil {
+		return nil, err
+	}
+	return s.store.History().ListByBooking(ctx, id)
+}
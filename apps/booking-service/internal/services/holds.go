@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// DefaultHoldTTL is how long a hold keeps its slot unless BookingService.HoldTTL says otherwise
+const DefaultHoldTTL = 10 * time.Minute
+
+var (
+	ErrHoldNotFound = apperr.NotFound("NOT_FOUND", "hold not found")
+	ErrHoldExpired  = apperr.Gone("HOLD_EXPIRED", "hold has expired")
+	ErrHoldMismatch = apperr.Validation("HOLD_MISMATCH", "booking does not match the held slot")
+)
+
+// CreateHold reserves a slot for the client while they check out. The slot must be bookable, and
+// holding it releases any earlier hold the client has on the same worker, so a client can't
+// tie up a worker's calendar.
+func (s *BookingService) CreateHold(ctx context.Context, clientID string, req *models.CreateHoldRequest) (*models.SlotHold, error) {
+	if !req.EndTime.After(req.StartTime) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	now := time.Now()
+	hold := &models.SlotHold{
+		ID:        uuid.New().String(),
+		WorkerID:  req.WorkerID,
+		ClientID:  clientID,
+		StartTime: req.StartTime,
+		EndTime:   req.EndTime,
+		ExpiresAt: now.Add(s.HoldTTL),
+		CreatedAt: now,
+	}
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		if err := tx.LockWorker(ctx, hold.WorkerID); err != nil {
+			return err
+		}
+
+		previous, err := tx.Holds().ListByClient(ctx, hold.WorkerID, clientID, now)
+		if err != nil {
+			return err
+		}
+		for _, p := range previous {
+			if err := tx.Holds().Delete(ctx, p.ID); err != nil {
+				return err
+			}
+		}
+
+		slot := slotRequest{WorkerID: hold.WorkerID, Start: hold.StartTime, End: hold.EndTime, HolderID: clientID}
+		if err := ensureBookable(ctx, tx, slot, now); err != nil {
+			return err
+		}
+
+		return tx.Holds().Create(ctx, hold)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+// ReleaseHold gives up a hold before it expires
+func (s *BookingService) ReleaseHold(ctx context.Context, holdID string, clientID string) error {
+	return s.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := getHold(ctx, tx, holdID, clientID, time.Now()); err != nil && !errors.Is(err, ErrHoldExpired) {
+			return err
+		}
+		return tx.Holds().Delete(ctx, holdID)
+	})
+}
+
+// getHold loads one of the client's holds, failing with ErrHoldExpired once it has lapsed
+func getHold(ctx context.Context, st store.Store, holdID string, clientID string, now time.Time) (*models.SlotHold, error) {
+	hold, err := st.Holds().Get(ctx, holdID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrHoldNotFound
+		}
+		return nil, err
+	}
+
+	if hold.ClientID != clientID {
+		return nil, ErrHoldNotFound
+	}
+	if !hold.ExpiresAt.After(now) {
+		return hold, ErrHoldExpired
+	}
+	return hold, nil
+}
+
+// applyHold fills the booking request in from the client's hold. Fields the client did send must
+// match what they held.
+func applyHold(hold *models.SlotHold, req *models.CreateBookingRequest) error {
+	if (req.WorkerID != "" && req.WorkerID != hold.WorkerID) ||
+		(!req.StartTime.IsZero() && !req.StartTime.Equal(hold.StartTime)) ||
+		(!req.EndTime.IsZero() && !req.EndTime.Equal(hold.EndTime)) {
+		return ErrHoldMismatch
+	}
+
+	req.WorkerID = hold.WorkerID
+	req.StartTime = hold.StartTime
+	req.EndTime = hold.EndTime
+	return nil
+}
+
+// ReleaseExpiredHolds deletes holds that have lapsed. Expired holds already stop blocking slots,
+// so this only keeps the table small.
+func (s *BookingService) ReleaseExpiredHolds(ctx context.Context) (int, error) {
+	return s.store.Holds().DeleteExpired(ctx, time.Now())
+}
+
+// RunHoldSweeper releases expired holds every interval until ctx is cancelled
+func (s *BookingService) RunHoldSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.ReleaseExpiredHolds(ctx); err != nil {
+			log.Printf("hold sweeper: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+const (
+	// DefaultPendingTTL is how long a worker has to respond to a booking before it expires
+	DefaultPendingTTL = 48 * time.Hour
+	// DefaultCompletionGrace is how long a finished session waits for the parties to complete
+	// it, or report a no-show, before it is completed automatically
+	DefaultCompletionGrace = 24 * time.Hour
+
+	lifecycleBatchSize = 100
+)
+
+// lifecycleStep moves bookings in status whose field is more than after in the past
+type lifecycleStep struct {
+	status string
+	field  string
+	after  time.Duration
+	action BookingAction
+	reason string
+}
+
+func (s *BookingService) lifecycleSteps() []lifecycleStep {
+	// In order, so a booking the scheduler fell behind on goes through every step in one run
+	return []lifecycleStep{
+		{models.BookingStatusPending, store.DueCreatedAt, s.PendingTTL, ActionExpire, "the worker did not respond in time"},
+		{models.BookingStatusPending, store.DueStartTime, 0, ActionExpire, "the booking was not confirmed before it started"},
+		{models.BookingStatusConfirmed, store.DueStartTime, 0, ActionStart, ""},
+		{models.BookingStatusInProgress, store.DueEndTime, 0, ActionAwaitCompletion, ""},
+		{models.BookingStatusAwaitingCompletion, store.DueEndTime, s.CompletionGrace, ActionComplete, "completed automatically after the grace period"},
+	}
+}
+
+// AdvanceLifecycle expires unanswered bookings, starts and finishes sessions as their times pass
+// and completes those nobody completed, returning how many bookings moved. Due bookings are
+// claimed with SKIP LOCKED and written conditionally on their version, so replicas running the
+// scheduler side by side never move the same booking twice.
+func (s *BookingService) AdvanceLifecycle(ctx context.Context) (int, error) {
+	moved := 0
+	for _, step := range s.lifecycleSteps() {
+		for {
+			claimed, n, err := s.advanceBatch(ctx, step, time.Now())
+			moved += n
+			if err != nil {
+				return moved, err
+			}
+			// Stop when the batch wasn't full, or when nothing in it could move
+			if claimed < lifecycleBatchSize || n == 0 {
+				break
+			}
+		}
+	}
+	return moved, nil
+}
+
+func (s *BookingService) advanceBatch(ctx context.Context, step lifecycleStep, now time.Time) (claimed int, moved int, err error) {
+	mc := &meetingChanges{}
+	err = s.store.WithTx(ctx, func(tx store.Store) error {
+		moved = 0
+		due, err := tx.Bookings().ListDue(ctx, step.status, step.field, now.Add(-step.after), lifecycleBatchSize)
+		if err != nil {
+			return err
+		}
+		claimed = len(due)
+
+		for _, b := range due {
+			// A booking that does not move keeps its room
+			bmc := &meetingChanges{}
+			err := s.advance(ctx, tx, b, step, bmc)
+			// Domain errors only concern this booking; the batch carries on without it
+			var appErr *apperr.Error
+			if errors.As(err, &appErr) {
+				log.Printf("lifecycle: %s booking %s: %v", step.action, b.ID, err)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			mc.dropped = append(mc.dropped, bmc.dropped...)
+			mc.needed = append(mc.needed, bmc.needed...)
+			moved++
+		}
+		return nil
+	})
+	s.settleMeetings(ctx, mc, err)
+	return claimed, moved, err
+}
+
+func (s *BookingService) advance(ctx context.Context, tx store.Store, b *models.Booking, step lifecycleStep, mc *meetingChanges) error {
+	status, err := s.states.Apply(b, step.action, "")
+	if err != nil {
+		return err
+	}
+
+	syncMeeting(b, status, mc)
+
+	if err := setBookingStatus(ctx, tx, b, status, "", step.action, step.reason); err != nil {
+		return err
+	}
+
+	if eventType, ok := transitionEvents[step.action]; ok {
+		return enqueueBookingEvent(ctx, tx, eventType, b.ID)
+	}
+	return nil
+}
+
+// RunLifecycleScheduler advances bookings, and retries the meeting rooms that could not be opened,
+// every interval until ctx is cancelled
+func (s *BookingService) RunLifecycleScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.AdvanceLifecycle(ctx); err != nil {
+			log.Printf("lifecycle scheduler: %v", err)
+		}
+		if _, err := s.OpenMissingMeetings(ctx, time.Now()); err != nil {
+			log.Printf("lifecycle scheduler: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
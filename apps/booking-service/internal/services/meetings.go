@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"log"
+	"slices"
+	"time"
+
+	"booking-service/internal/events"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+// MeetingProvider opens the conferencing rooms remote bookings are held in
+type MeetingProvider interface {
+	// CreateRoom opens a room for the booking and returns the URL to join it
+	CreateRoom(ctx context.Context, b *models.Booking) (string, error)
+	// RevokeRoom closes a room CreateRoom opened, so its URL no longer works
+	RevokeRoom(ctx context.Context, url string) error
+}
+
+// actionOpenMeeting records a room linked to a booking after the change that needed it
+const actionOpenMeeting BookingAction = "open_meeting"
+
+// meetingStatuses are the statuses a remote booking has a room in
+var meetingStatuses = []string{
+	models.BookingStatusConfirmed,
+	models.BookingStatusInProgress,
+	models.BookingStatusAwaitingCompletion,
+}
+
+// closedStatuses are the statuses of bookings that will not take place, whose rooms are revoked.
+// Finished bookings keep the link they were held on.
+var closedStatuses = []string{
+	models.BookingStatusCancelled,
+	models.BookingStatusDeclined,
+	models.BookingStatusExpired,
+}
+
+// meetingChanges tracks the rooms one transaction gave up and the bookings it left needing one.
+// Calling the provider while the transaction holds the booking and worker locks would keep them
+// for as long as the provider takes, so rooms are only opened and revoked once it has committed.
+type meetingChanges struct {
+	dropped []string
+	needed  []string
+}
+
+// syncMeeting notes that a remote booking moving into status needs a room if it has none, and
+// takes the room away from a booking that will no longer take place or is no longer remote
+func syncMeeting(b *models.Booking, status string, mc *meetingChanges) {
+	if b.MeetingURL != nil && (!b.IsRemote || slices.Contains(closedStatuses, status)) {
+		dropMeeting(b, mc)
+		return
+	}
+	if b.MeetingURL == nil && b.IsRemote && slices.Contains(meetingStatuses, status) {
+		mc.needed = append(mc.needed, b.ID)
+	}
+}
+
+// regenerateMeeting replaces the booking's room with a new one, e.g. when it is moved, so the old
+// link stops working
+func regenerateMeeting(b *models.Booking, mc *meetingChanges) {
+	if b.MeetingURL == nil {
+		return
+	}
+	dropMeeting(b, mc)
+	syncMeeting(b, b.Status, mc)
+}
+
+func dropMeeting(b *models.Booking, mc *meetingChanges) {
+	mc.dropped = append(mc.dropped, *b.MeetingURL)
+	b.MeetingURL = nil
+}
+
+// settleMeetings applies the meeting changes of a transaction that ended with err. Once it has
+// committed, the rooms given up are revoked and the bookings that need one get one. Failures are
+// only logged: the lifecycle scheduler retries bookings still missing their room.
+func (s *BookingService) settleMeetings(ctx context.Context, mc *meetingChanges, err error) {
+	if err != nil || s.Meetings == nil {
+		return
+	}
+	for _, url := range mc.dropped {
+		if err := s.Meetings.RevokeRoom(ctx, url); err != nil {
+			log.Printf("meetings: revoke room %s: %v", url, err)
+		}
+	}
+	for _, id := range mc.needed {
+		if err := s.openMeeting(ctx, id); err != nil {
+			log.Printf("meetings: open room for booking %s: %v", id, err)
+		}
+	}
+}
+
+// openMeeting opens a room for the booking and links it. The link is only written if the booking
+// is unchanged since it was read; otherwise the room is revoked and the booking is left for the
+// next attempt.
+func (s *BookingService) openMeeting(ctx context.Context, id string) error {
+	b, err := s.store.Bookings().Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if b.MeetingURL != nil || !b.IsRemote || !slices.Contains(meetingStatuses, b.Status) {
+		return nil
+	}
+
+	url, err := s.Meetings.CreateRoom(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.WithTx(ctx, func(tx store.Store) error {
+		before := *b
+		b.MeetingURL = &url
+		b.UpdatedAt = time.Now()
+		if err := updateBooking(ctx, tx, &before, b, "", actionOpenMeeting); err != nil {
+			return err
+		}
+		return enqueueBookingEvent(ctx, tx, events.BookingMeetingReady, b.ID)
+	})
+	if err != nil {
+		if rerr := s.Meetings.RevokeRoom(ctx, url); rerr != nil {
+			log.Printf("meetings: revoke room %s: %v", url, rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// OpenMissingMeetings opens rooms for confirmed remote bookings that have none yet because the
+// provider failed when they were confirmed, returning how many got one
+func (s *BookingService) OpenMissingMeetings(ctx context.Context, now time.Time) (int, error) {
+	if s.Meetings == nil {
+		return 0, nil
+	}
+	missing, err := s.store.Bookings().List(ctx, store.BookingQuery{Statuses: meetingStatuses, MissingMeeting: true, EndsAfter: now, Limit: lifecycleBatchSize})
+	if err != nil {
+		return 0, err
+	}
+
+	opened := 0
+	for _, b := range missing {
+		if err := s.openMeeting(ctx, b.ID); err != nil {
+			log.Printf("meetings: open room for booking %s: %v", b.ID, err)
+			continue
+		}
+		opened++
+	}
+	return opened, nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"booking-service/internal/events"
+	"booking-service/internal/meeting"
+	"booking-service/internal/models"
+)
+
+func TestMeetingRooms(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		remote bool
+		// providerDown makes the provider fail while the booking is confirmed
+		providerDown bool
+		cancel       bool
+		// linkedOnConfirm is whether confirming returns the booking with its link
+		linkedOnConfirm bool
+		// retried is how many rooms the scheduler's retry opens
+		retried   int
+		wantRooms int
+		wantReady int
+	}{
+		{name: "confirming opens a room", remote: true, linkedOnConfirm: true, wantRooms: 1, wantReady: 1},
+		{name: "bookings in person get no room"},
+		{name: "a failing provider leaves the booking for the scheduler", remote: true, providerDown: true, retried: 1, wantRooms: 1, wantReady: 1},
+		{name: "cancelling revokes the room", remote: true, cancel: true, linkedOnConfirm: true, wantReady: 1},
+		{name: "cancelled bookings are not retried", remote: true, providerDown: true, cancel: true},
+	}
+	for _, tt := range tests {
+		s, st := newTestService(t)
+		provider := meeting.NewFakeProvider()
+		s.Meetings = provider
+
+		start := daysAhead(3, 10)
+		b, err := s.CreateBooking(ctx, testClientID, &models.CreateBookingRequest{
+			WorkerID: testWorkerID, Title: "Session", StartTime: start, EndTime: start.Add(time.Hour), IsRemote: tt.remote,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if tt.providerDown {
+			provider.Fail(errors.New("provider down"))
+		}
+		b, err = s.ConfirmBooking(ctx, b.ID, testWorkerID, 0)
+		if err != nil {
+			t.Fatalf("%s: confirm: %v", tt.name, err)
+		}
+		if b.Status != models.BookingStatusConfirmed || (b.MeetingURL != nil) != tt.linkedOnConfirm {
+			t.Errorf("%s: confirmed booking is %s with link %v, want confirmed with link %v", tt.name, b.Status, b.MeetingURL, tt.linkedOnConfirm)
+		}
+		provider.Fail(nil)
+
+		if tt.cancel {
+			if _, err := s.CancelBooking(ctx, b.ID, testClientID, 0, "plans changed"); err != nil {
+				t.Fatalf("%s: cancel: %v", tt.name, err)
+			}
+		}
+
+		retried, err := s.OpenMissingMeetings(ctx, time.Now())
+		if err != nil || retried != tt.retried {
+			t.Errorf("%s: OpenMissingMeetings = %d, %v, want %d", tt.name, retried, err, tt.retried)
+		}
+
+		b, err = s.GetBookingByID(ctx, b.ID, testClientID)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		rooms := provider.Rooms()
+		if len(rooms) != tt.wantRooms {
+			t.Errorf("%s: %d rooms open, want %d", tt.name, len(rooms), tt.wantRooms)
+		}
+		if tt.wantRooms > 0 && (b.MeetingURL == nil || *b.MeetingURL != rooms[0]) {
+			t.Errorf("%s: booking links to %v, want %s", tt.name, b.MeetingURL, rooms[0])
+		}
+		if tt.wantRooms == 0 && b.MeetingURL != nil {
+			t.Errorf("%s: booking links to %s, want no link", tt.name, *b.MeetingURL)
+		}
+
+		now := time.Now()
+		ready, err := st.Outbox().Claim(ctx, now, now.Add(time.Minute), []string{events.BookingMeetingReady}, 10)
+		if err != nil || len(ready) != tt.wantReady {
+			t.Errorf("%s: %d %s events, %v, want %d", tt.name, len(ready), events.BookingMeetingReady, err, tt.wantReady)
+		}
+		// The link itself stays out of events
+		for _, e := range ready {
+			if !bytes.Contains(e.Payload, []byte(`"hasMeetingUrl":true`)) || bytes.Contains(e.Payload, []byte("meetingUrl\"")) {
+				t.Errorf("%s: %s payload %s, want hasMeetingUrl without the link", tt.name, events.BookingMeetingReady, e.Payload)
+			}
+		}
+	}
+}
+
+// A link that lost the race with another change to the booking is revoked again
+func TestOpenMeetingRevokesOnConflict(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService(t)
+	provider := meeting.NewFakeProvider()
+	s.Meetings = provider
+
+	start := daysAhead(3, 10)
+	b, err := s.CreateBooking(ctx, testClientID, &models.CreateBookingRequest{
+		WorkerID: testWorkerID, Title: "Session", StartTime: start, EndTime: start.Add(time.Hour), IsRemote: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	provider.Fail(errors.New("provider down"))
+	if _, err := s.ConfirmBooking(ctx, b.ID, testWorkerID, 0); err != nil {
+		t.Fatal(err)
+	}
+	provider.Fail(nil)
+
+	// Another request changes the booking while its room is being opened
+	s.Meetings = racingProvider{provider, func() {
+		b, err := st.Bookings().Get(ctx, b.ID)
+		if err == nil {
+			b.UpdatedAt = time.Now()
+			err = st.Bookings().Update(ctx, b)
+		}
+		if err != nil {
+			t.Error(err)
+		}
+	}}
+	opened, err := s.OpenMissingMeetings(ctx, time.Now())
+	if err != nil || opened != 0 {
+		t.Errorf("OpenMissingMeetings = %d, %v, want 0", opened, err)
+	}
+	if rooms := provider.Rooms(); len(rooms) != 0 {
+		t.Errorf("rooms %v are still open", rooms)
+	}
+	stored, err := st.Bookings().Get(ctx, b.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.MeetingURL != nil {
+		t.Errorf("booking links to %s, want no link", *stored.MeetingURL)
+	}
+}
+
+// racingProvider runs race just before each room it opens is handed back
+type racingProvider struct {
+	*meeting.FakeProvider
+	race func()
+}
+
+func (p racingProvider) CreateRoom(ctx context.Context, b *models.Booking) (string, error) {
+	url, err := p.FakeProvider.CreateRoom(ctx, b)
+	p.race()
+	return url, err
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"booking-service/internal/events"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// transitionEvents lists the status changes other services subscribe to. Cancellations are sent
+// by cancelBooking along with their refund outcome. Starting a session and awaiting its
+// completion follow from the booking's times, which subscribers already have, so they are silent.
+var transitionEvents = map[BookingAction]string{
+	ActionConfirm:  events.BookingConfirmed,
+	ActionDecline:  events.BookingDeclined,
+	ActionComplete: events.BookingCompleted,
+	ActionNoShow:   events.BookingNoShow,
+	ActionExpire:   events.BookingExpired,
+}
+
+// enqueueBookingEvent records an event in the outbox. It must run on the transaction that
+// changes the booking so the event is stored if and only if the change commits.
+// The payload carries the booking as it stands after the change.
+func enqueueBookingEvent(ctx context.Context, st store.Store, eventType string, bookingID string) error {
+	booking, err := st.Bookings().Get(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	return enqueueEvent(ctx, st, eventType, booking.ID, newBookingEventData(booking))
+}
+
+// bookingEventData is a booking as published in events. Meeting links let anyone holding them
+// in, so events only say whether there is one and subscribers read it from the API.
+type bookingEventData struct {
+	models.Booking
+	HasMeetingURL bool `json:"hasMeetingUrl"`
+}
+
+func newBookingEventData(b *models.Booking) bookingEventData {
+	data := bookingEventData{Booking: *b, HasMeetingURL: b.MeetingURL != nil}
+	data.MeetingURL = nil
+	return data
+}
+
+// enqueueEvent records an event about the booking with the given payload in the outbox
+func enqueueEvent(ctx context.Context, st store.Store, eventType string, bookingID string, data any) error {
+	event := events.Event{
+		ID:          uuid.New().String(),
+		Type:        eventType,
+		AggregateID: bookingID,
+		OccurredAt:  time.Now().UTC(),
+		Data:        data,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return st.Outbox().Add(ctx, &store.OutboxEvent{
+		ID:            event.ID,
+		AggregateType: "booking",
+		AggregateID:   bookingID,
+		EventType:     eventType,
+		Payload:       payload,
+		NextAttemptAt: event.OccurredAt,
+		CreatedAt:     event.OccurredAt,
+	})
+}
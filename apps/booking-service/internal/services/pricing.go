@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+	"booking-service/internal/money"
+	"booking-service/internal/store"
+)
+
+var (
+	ErrWorkerNotPriced = apperr.Validation("WORKER_NOT_PRICED", "worker has not set an hourly rate")
+	ErrInvalidRateCard = apperr.Validation("VALIDATION_ERROR", "invalid rate card")
+	ErrPriceOutOfRange = apperr.Validation("PRICE_OUT_OF_RANGE", "the price is too large to book")
+)
+
+const (
+	defaultWorkdayStart = "08:00"
+	defaultWorkdayEnd   = "18:00"
+)
+
+// NormalizeRateCard fills in the default workday and checks the card is usable
+func NormalizeRateCard(card *models.RateCard) error {
+	if card.HourlyRate != nil && (card.HourlyRate.IsNegative() || card.HourlyRate.IsZero()) {
+		return fmt.Errorf("%w: hourly rate must be positive", ErrInvalidRateCard)
+	}
+	if card.RushFee.IsNegative() {
+		return fmt.Errorf("%w: rush fee must not be negative", ErrInvalidRateCard)
+	}
+	if !card.RushFee.IsZero() && card.RushNoticeHours == 0 {
+		return fmt.Errorf("%w: a rush fee needs rushNoticeHours", ErrInvalidRateCard)
+	}
+
+	if card.WorkdayStart == "" {
+		card.WorkdayStart = defaultWorkdayStart
+	}
+	if card.WorkdayEnd == "" {
+		card.WorkdayEnd = defaultWorkdayEnd
+	}
+	start, errStart := time.Parse("15:04", card.WorkdayStart)
+	end, errEnd := time.Parse("15:04", card.WorkdayEnd)
+	if errStart != nil || errEnd != nil {
+		return fmt.Errorf("%w: workday times must be formatted as HH:MM", ErrInvalidRateCard)
+	}
+	if !end.After(start) {
+		return fmt.Errorf("%w: workdayEnd must be after workdayStart", ErrInvalidRateCard)
+	}
+	return nil
+}
+
+func workerRateCard(settings *store.WorkerSettings) models.RateCard {
+	if settings.RateCard != nil {
+		return *settings.RateCard
+	}
+	return models.RateCard{WorkdayStart: defaultWorkdayStart, WorkdayEnd: defaultWorkdayEnd}
+}
+
+// workerRate is the worker's hourly rate rounded to their currency. The rate card's own rate wins
+// over the one on the profile.
+func workerRate(settings *store.WorkerSettings) (money.Amount, string, error) {
+	currency, err := settingsCurrency(settings)
+	if err != nil {
+		return money.Amount{}, "", err
+	}
+
+	rate := settings.HourlyRate
+	if settings.RateCard != nil && settings.RateCard.HourlyRate != nil {
+		rate = settings.RateCard.HourlyRate
+	}
+	if rate == nil || rate.IsNegative() || rate.IsZero() {
+		return money.Amount{}, "", ErrWorkerNotPriced
+	}
+
+	rounded, err := rate.Round(currency)
+	return rounded, currency, err
+}
+
+// quotePrice prices start to end against the worker's pricing as of now. Every line is rounded to
+// the currency's minor unit on its own, so the total is exactly the sum of the lines.
+func quotePrice(settings *store.WorkerSettings, start, end time.Time, now time.Time) (*models.PriceBreakdown, error) {
+	if !end.After(start) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	rate, currency, err := workerRate(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	card := workerRateCard(settings)
+	minutes, weekend, afterHours := classifyMinutes(card, workerLocation(settings), start, end)
+
+	quote := &models.PriceBreakdown{Currency: currency, HourlyRate: rate, Lines: []models.PriceLine{}, QuotedAt: now}
+	addLine := func(line models.PriceLine) {
+		line.Amount = roundMoney(line.Amount, currency)
+		quote.Lines = append(quote.Lines, line)
+		quote.Total = quote.Total.Add(line.Amount)
+	}
+
+	base, err := rate.MulRat(int64(minutes), 60)
+	if err != nil {
+		return nil, priceError(err)
+	}
+	addLine(models.PriceLine{Type: models.PriceLineBase, Minutes: minutes, Amount: base})
+	if weekend > 0 && card.WeekendSurchargePercent > 0 {
+		amount, err := rate.MulRat(int64(weekend)*int64(card.WeekendSurchargePercent), 6000)
+		if err != nil {
+			return nil, priceError(err)
+		}
+		addLine(models.PriceLine{
+			Type:    models.PriceLineWeekend,
+			Minutes: weekend,
+			Percent: card.WeekendSurchargePercent,
+			Amount:  amount,
+		})
+	}
+	if afterHours > 0 && card.AfterHoursSurchargePercent > 0 {
+		amount, err := rate.MulRat(int64(afterHours)*int64(card.AfterHoursSurchargePercent), 6000)
+		if err != nil {
+			return nil, priceError(err)
+		}
+		addLine(models.PriceLine{
+			Type:    models.PriceLineAfterHours,
+			Minutes: afterHours,
+			Percent: card.AfterHoursSurchargePercent,
+			Amount:  amount,
+		})
+	}
+	if card.RushNoticeHours > 0 && !card.RushFee.IsZero() && start.Sub(now) < time.Duration(card.RushNoticeHours)*time.Hour {
+		addLine(models.PriceLine{Type: models.PriceLineRush, Amount: card.RushFee})
+	}
+
+	return quote, nil
+}
+
+// priceError reports a price too large to represent as a domain error
+func priceError(err error) error {
+	if errors.Is(err, money.ErrOverflow) {
+		return ErrPriceOutOfRange
+	}
+	return err
+}
+
+// classifyMinutes counts the whole minutes of the slot, as recorded in the booking's duration, and
+// how many of them fall on a weekend or outside the workday, on the worker's wall clock. Minutes
+// are counted from the start and classified by the instant they begin; runs of minutes up to the
+// next midnight or workday boundary are classified together.
+func classifyMinutes(card models.RateCard, loc *time.Location, start, end time.Time) (total, weekend, afterHours int) {
+	dayStartHour, dayStartMin := parseTimeString(card.WorkdayStart)
+	dayEndHour, dayEndMin := parseTimeString(card.WorkdayEnd)
+	dayStart := dayStartHour*60 + dayStartMin
+	dayEnd := dayEndHour*60 + dayEndMin
+
+	// minutesUntil is how many of the counted minutes start before t
+	minutesUntil := func(t time.Time) int {
+		d := t.Sub(start)
+		if d%time.Minute > 0 {
+			return int(d/time.Minute) + 1
+		}
+		return int(d / time.Minute)
+	}
+
+	total = wholeMinutes(start, end)
+	for i := 0; i < total; {
+		local := start.Add(time.Duration(i) * time.Minute).In(loc)
+		year, month, day := local.Date()
+
+		// Find where this run of minutes ends and which count it belongs to
+		next := time.Date(year, month, day+1, 0, 0, 0, 0, loc)
+		var count *int
+		switch minute := local.Hour()*60 + local.Minute(); {
+		case local.Weekday() == time.Saturday || local.Weekday() == time.Sunday:
+			count = &weekend
+		case minute < dayStart:
+			next = time.Date(year, month, day, 0, dayStart, 0, 0, loc)
+			count = &afterHours
+		case minute < dayEnd:
+			next = time.Date(year, month, day, 0, dayEnd, 0, 0, loc)
+		default:
+			count = &afterHours
+		}
+
+		// A DST change can put the boundary behind us; always move on by at least a minute
+		j := max(min(minutesUntil(next), total), i+1)
+		if count != nil {
+			*count += j - i
+		}
+		i = j
+	}
+	return total, weekend, afterHours
+}
+
+// priceBooking quotes the booking's slot and stores the breakdown and total on it
+func priceBooking(settings *store.WorkerSettings, b *models.Booking, now time.Time) error {
+	quote, err := quotePrice(settings, b.StartTime, b.EndTime, now)
+	if err != nil {
+		return err
+	}
+
+	b.Currency = quote.Currency
+	b.HourlyRate = quote.HourlyRate
+	b.TotalAmount = quote.Total
+	b.PriceBreakdown = quote
+	return nil
+}
+
+// QuoteBooking prices a proposed slot without booking it. The slot must be a length the worker
+// takes bookings for.
+func (s *BookingService) QuoteBooking(ctx context.Context, req *models.QuoteRequest) (*models.PriceBreakdown, error) {
+	if !req.EndTime.After(req.StartTime) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	settings, err := s.store.Availability().Get(ctx, req.WorkerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Slots a booking could never have aren't priced
+	if err := checkDuration(scheduleFromSettings(settings).Rules, req.EndTime.Sub(req.StartTime)); err != nil {
+		return nil, err
+	}
+	return quotePrice(settings, req.StartTime, req.EndTime, time.Now())
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"booking-service/internal/models"
+	"booking-service/internal/money"
+	"booking-service/internal/store"
+)
+
+func TestClassifyMinutes(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	card := models.RateCard{WorkdayStart: "08:00", WorkdayEnd: "18:00"}
+	// 2026-10-14 is a Wednesday
+	wed := func(hour, min, sec int) time.Time { return time.Date(2026, 10, 14, hour, min, sec, 0, time.UTC) }
+
+	tests := []struct {
+		name                       string
+		loc                        *time.Location
+		start, end                 time.Time
+		total, weekend, afterHours int
+	}{
+		{name: "inside the workday", loc: time.UTC, start: wed(10, 0, 0), end: wed(11, 0, 0), total: 60},
+		{name: "across the workday end", loc: time.UTC, start: wed(17, 30, 0), end: wed(18, 30, 0), total: 60, afterHours: 30},
+		{name: "across the workday start", loc: time.UTC, start: wed(7, 0, 0), end: wed(9, 0, 0), total: 120, afterHours: 60},
+		{name: "ending on the workday end", loc: time.UTC, start: wed(17, 0, 0), end: wed(18, 0, 0), total: 60},
+		{name: "starting on the workday end", loc: time.UTC, start: wed(18, 0, 0), end: wed(19, 0, 0), total: 60, afterHours: 60},
+		{name: "evening into early morning", loc: time.UTC, start: wed(22, 0, 0), end: wed(22, 0, 0).Add(11 * time.Hour), total: 660, afterHours: 600},
+		{name: "friday night into saturday", loc: time.UTC, start: time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC), end: time.Date(2026, 10, 17, 1, 0, 0, 0, time.UTC), total: 120, weekend: 60, afterHours: 60},
+		{name: "sunday night into monday", loc: time.UTC, start: time.Date(2026, 10, 18, 23, 30, 0, 0, time.UTC), end: time.Date(2026, 10, 19, 0, 30, 0, 0, time.UTC), total: 60, weekend: 30, afterHours: 30},
+		{name: "on the worker's wall clock", loc: berlin, start: wed(15, 30, 0), end: wed(16, 30, 0), total: 60, afterHours: 30},
+		// The partial last minute is not counted, like the booking's duration
+		{name: "trailing partial minute", loc: time.UTC, start: wed(10, 0, 0), end: wed(10, 30, 30), total: 30},
+		// A minute belongs to the segment it starts in
+		{name: "minute straddling the workday end", loc: time.UTC, start: wed(17, 59, 30), end: wed(18, 1, 30), total: 2, afterHours: 1},
+		// 2026-03-29 02:00-03:00 does not exist in Berlin; the run still covers every real minute
+		{name: "across a DST gap", loc: berlin, start: time.Date(2026, 3, 28, 23, 30, 0, 0, time.UTC), end: time.Date(2026, 3, 29, 1, 30, 0, 0, time.UTC), total: 120, weekend: 120},
+		// 2026-10-25 02:00-03:00 happens twice in Berlin, so that Sunday lasts 25 hours
+		{name: "across a DST overlap", loc: berlin, start: time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC), end: time.Date(2026, 10, 25, 23, 30, 0, 0, time.UTC), total: 1410, weekend: 1380, afterHours: 30},
+	}
+	for _, tt := range tests {
+		total, weekend, afterHours := classifyMinutes(card, tt.loc, tt.start, tt.end)
+		if total != tt.total || weekend != tt.weekend || afterHours != tt.afterHours {
+			t.Errorf("%s: classifyMinutes = %d, %d, %d, want %d, %d, %d", tt.name, total, weekend, afterHours, tt.total, tt.weekend, tt.afterHours)
+		}
+	}
+}
+
+func TestQuotePrice(t *testing.T) {
+	rate := money.MustParse("40")
+	settings := &store.WorkerSettings{
+		Currency: "USD",
+		RateCard: &models.RateCard{
+			HourlyRate:                 &rate,
+			WorkdayStart:               "08:00",
+			WorkdayEnd:                 "18:00",
+			WeekendSurchargePercent:    50,
+			AfterHoursSurchargePercent: 25,
+			RushFee:                    money.MustParse("15"),
+			RushNoticeHours:            24,
+		},
+	}
+	now := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	// 2026-10-17 is a Saturday
+	saturday := time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		lines      []string
+		total      string
+	}{
+		{name: "plain hour", start: saturday.AddDate(0, 0, -3), end: saturday.AddDate(0, 0, -3).Add(time.Hour), lines: []string{"base 60 40"}, total: "40"},
+		{name: "weekend", start: saturday, end: saturday.Add(90 * time.Minute), lines: []string{"base 90 60", "weekend 90 30"}, total: "90"},
+		{name: "after hours", start: saturday.AddDate(0, 0, -3).Add(7 * time.Hour), end: saturday.AddDate(0, 0, -3).Add(9 * time.Hour), lines: []string{"base 120 80", "after_hours 60 10"}, total: "90"},
+		{name: "rush", start: now.Add(2 * time.Hour), end: now.Add(3 * time.Hour), lines: []string{"base 60 40", "rush 0 15"}, total: "55"},
+		{name: "partial minute is free", start: saturday.AddDate(0, 0, -3), end: saturday.AddDate(0, 0, -3).Add(time.Minute + 59*time.Second), lines: []string{"base 1 0.67"}, total: "0.67"},
+	}
+	for _, tt := range tests {
+		quote, err := quotePrice(settings, tt.start, tt.end, now)
+		if err != nil {
+			t.Errorf("%s: quotePrice: %v", tt.name, err)
+			continue
+		}
+		var lines []string
+		for _, line := range quote.Lines {
+			lines = append(lines, fmt.Sprintf("%s %d %s", line.Type, line.Minutes, line.Amount))
+		}
+		if !slices.Equal(lines, tt.lines) || quote.Total.String() != tt.total {
+			t.Errorf("%s: quote = %v totalling %s, want %v totalling %s", tt.name, lines, quote.Total, tt.lines, tt.total)
+		}
+	}
+}
+
+func TestQuotePriceRejectsOverflow(t *testing.T) {
+	rate := money.MustParse("900000000000000")
+	settings := &store.WorkerSettings{Currency: "USD", HourlyRate: &rate}
+	start := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+	if _, err := quotePrice(settings, start, start.Add(2*time.Hour), start); err != ErrPriceOutOfRange {
+		t.Fatalf("quotePrice error = %v, want ErrPriceOutOfRange", err)
+	}
+}
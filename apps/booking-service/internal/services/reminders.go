@@ -0,0 +1,269 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"booking-service/internal/events"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+
+	"github.com/google/uuid"
+)
+
+// DefaultReminderOffsets are the minutes before the start users are reminded at, unless they set
+// their own
+var DefaultReminderOffsets = []int{24 * 60, 60}
+
+const reminderBatchSize = 100
+
+// remindedStatuses are the statuses of bookings that are still going ahead
+var remindedStatuses = []string{models.BookingStatusPending, models.BookingStatusConfirmed}
+
+// scheduleReminders replaces the booking's unsent reminders with those its worker and client
+// want, or with none once the booking is no longer going ahead. It runs whenever a booking is
+// created, moved or changes status. Reminders already due are skipped, and one already sent for
+// the same time isn't sent again.
+func scheduleReminders(ctx context.Context, st store.Store, b *models.Booking) error {
+	var list []*models.BookingReminder
+	if slices.Contains(remindedStatuses, b.Status) {
+		existing, err := st.Reminders().ListByBooking(ctx, b.ID)
+		if err != nil {
+			return err
+		}
+		sent := func(userID string, dueAt time.Time) bool {
+			return slices.ContainsFunc(existing, func(r *models.BookingReminder) bool {
+				return r.SentAt != nil && r.UserID == userID && r.DueAt.Equal(dueAt)
+			})
+		}
+
+		now := time.Now()
+		for _, userID := range []string{b.WorkerID, b.ClientID} {
+			offsets, err := reminderOffsets(ctx, st, userID)
+			if err != nil {
+				return err
+			}
+			for _, offset := range offsets {
+				dueAt := b.StartTime.Add(-time.Duration(offset) * time.Minute)
+				if !dueAt.After(now) || sent(userID, dueAt) {
+					continue
+				}
+				list = append(list, &models.BookingReminder{
+					ID:            uuid.New().String(),
+					BookingID:     b.ID,
+					UserID:        userID,
+					OffsetMinutes: offset,
+					DueAt:         dueAt,
+					CreatedAt:     now,
+				})
+			}
+		}
+	}
+
+	return st.Reminders().ReplacePending(ctx, b.ID, list)
+}
+
+// reminderOffsets returns the user's own offsets, or the defaults
+func reminderOffsets(ctx context.Context, st store.Store, userID string) ([]int, error) {
+	offsets, err := st.Reminders().GetOffsets(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return DefaultReminderOffsets, nil
+	}
+	return offsets, err
+}
+
+// GetBookingReminders returns the caller's reminders for the booking, soonest first
+func (s *BookingService) GetBookingReminders(ctx context.Context, id string, userID string) ([]*models.BookingReminder, error) {
+	if _, err := getBooking(ctx, s.store, id, userID); err != nil {
+		return nil, err
+	}
+
+	all, err := s.store.Reminders().ListByBooking(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*models.BookingReminder, 0, len(all))
+	for _, r := range all {
+		if r.UserID == userID {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+func (s *BookingService) GetReminderPreferences(ctx context.Context, userID string) (*models.ReminderPreferences, error) {
+	offsets, err := s.store.Reminders().GetOffsets(ctx, userID)
+	if errors.Is(err, store.ErrNotFound) {
+		return &models.ReminderPreferences{OffsetsMinutes: DefaultReminderOffsets, IsDefault: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReminderPreferences{OffsetsMinutes: offsets}, nil
+}
+
+// SetReminderPreferences overrides when the user is reminded and reschedules the reminders of
+// their upcoming bookings. A nil offsets list goes back to the defaults.
+func (s *BookingService) SetReminderPreferences(ctx context.Context, userID string, offsets []int) (*models.ReminderPreferences, error) {
+	if offsets != nil {
+		offsets = slices.Clone(offsets)
+		slices.Sort(offsets)
+		slices.Reverse(offsets)
+		offsets = slices.Compact(offsets)
+	}
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		if offsets == nil {
+			err = tx.Reminders().DeleteOffsets(ctx, userID)
+		} else {
+			err = tx.Reminders().SetOffsets(ctx, userID, offsets)
+		}
+		if err != nil {
+			return err
+		}
+
+		for _, role := range []string{string(PartyWorker), string(PartyClient)} {
+			upcoming, err := tx.Bookings().List(ctx, store.BookingQuery{UserID: userID, Role: role, Statuses: remindedStatuses, EndsAfter: time.Now()})
+			if err != nil {
+				return err
+			}
+			for _, b := range upcoming {
+				if err := scheduleReminders(ctx, tx, b); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetReminderPreferences(ctx, userID)
+}
+
+// DispatchDueReminders queues every reminder that has come due as a booking.reminder event and
+// returns how many it queued. Reminders are claimed with SKIP LOCKED and marked sent in the same
+// transaction as the event is stored, so each is queued exactly once however many replicas run
+// the dispatcher. The outbox relay then delivers the event's notification to the notification
+// service.
+func (s *BookingService) DispatchDueReminders(ctx context.Context) (int, error) {
+	sent := 0
+	for {
+		claimed, queued := 0, 0
+		err := s.store.WithTx(ctx, func(tx store.Store) error {
+			now := time.Now()
+			due, err := tx.Reminders().ListDue(ctx, now, reminderBatchSize)
+			if err != nil {
+				return err
+			}
+			claimed = len(due)
+
+			for _, r := range due {
+				ok, err := sendReminder(ctx, tx, r, now)
+				if err != nil {
+					return err
+				}
+				if ok {
+					queued++
+				}
+			}
+			return nil
+		})
+		// A batch that rolled back queued nothing
+		if err != nil {
+			return sent, err
+		}
+		sent += queued
+		if claimed < reminderBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// sendReminder stores the reminder's notification in the outbox and marks it sent. A reminder
+// whose booking has started or stopped going ahead since it was scheduled is dropped instead.
+func sendReminder(ctx context.Context, st store.Store, r *models.BookingReminder, now time.Time) (bool, error) {
+	b, err := st.Bookings().Get(ctx, r.BookingID)
+	if errors.Is(err, store.ErrNotFound) {
+		return false, st.Reminders().Delete(ctx, r.ID)
+	}
+	if err != nil {
+		return false, err
+	}
+	if !slices.Contains(remindedStatuses, b.Status) || !now.Before(b.StartTime) {
+		return false, st.Reminders().Delete(ctx, r.ID)
+	}
+
+	notification := &models.ReminderNotification{
+		UserID: r.UserID,
+		Type:   "booking_reminder",
+		Title:  "Upcoming booking",
+		Body:   fmt.Sprintf("%q starts in %s", b.Title, formatOffset(minutesLeft(b.StartTime.Sub(now)))),
+		Data: map[string]any{
+			"bookingId":     b.ID,
+			"reminderId":    r.ID,
+			"startTime":     b.StartTime,
+			"status":        b.Status,
+			"offsetMinutes": r.OffsetMinutes,
+		},
+		Channels: []string{"in_app", "email", "push"},
+	}
+	if err := enqueueEvent(ctx, st, events.BookingReminder, b.ID, notification); err != nil {
+		return false, err
+	}
+	return true, st.Reminders().MarkSent(ctx, r.ID, now)
+}
+
+// minutesLeft rounds the time until a booking starts to what is worth saying in a reminder: the
+// minute under two hours, the hour under two days and the day after that. The dispatcher may run
+// late, so this rather than the reminder's offset is what the booking is still away.
+func minutesLeft(d time.Duration) int {
+	switch {
+	case d < 2*time.Hour:
+		d = d.Round(time.Minute)
+	case d < 48*time.Hour:
+		d = d.Round(time.Hour)
+	default:
+		d = d.Round(24 * time.Hour)
+	}
+	return max(int(d/time.Minute), 1)
+}
+
+// formatOffset renders minutes the way people say them: "1 day", "2 hours", "90 minutes"
+func formatOffset(minutes int) string {
+	value, unit := minutes, "minute"
+	switch {
+	case minutes%(24*60) == 0:
+		value, unit = minutes/(24*60), "day"
+	case minutes%60 == 0:
+		value, unit = minutes/60, "hour"
+	}
+	if value != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s", value, unit)
+}
+
+// RunReminderDispatcher sends due reminders every interval until ctx is cancelled
+func (s *BookingService) RunReminderDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.DispatchDueReminders(ctx); err != nil {
+			log.Printf("reminder dispatcher: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
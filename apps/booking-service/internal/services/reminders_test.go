@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"booking-service/internal/events"
+	"booking-service/internal/models"
+	"booking-service/internal/store/memory"
+)
+
+func TestScheduleReminders(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		status        string
+		startIn       time.Duration
+		clientOffsets []int
+		// sentToClient is an offset the client was already reminded at for the same start
+		sentToClient int
+		want         []string
+	}{
+		{
+			name: "default offsets", status: models.BookingStatusConfirmed, startIn: 72 * time.Hour,
+			want: []string{"client 1440", "client 60", "worker 1440", "worker 60"},
+		},
+		{
+			name: "offsets already past are skipped", status: models.BookingStatusPending, startIn: 2 * time.Hour,
+			want: []string{"client 60", "worker 60"},
+		},
+		{
+			name: "the client's own offsets", status: models.BookingStatusConfirmed, startIn: 72 * time.Hour, clientOffsets: []int{30},
+			want: []string{"client 30", "worker 1440", "worker 60"},
+		},
+		{
+			name: "client turned reminders off", status: models.BookingStatusConfirmed, startIn: 72 * time.Hour, clientOffsets: []int{},
+			want: []string{"worker 1440", "worker 60"},
+		},
+		{
+			name: "a sent reminder is not sent again", status: models.BookingStatusConfirmed, startIn: 72 * time.Hour, sentToClient: 1440,
+			want: []string{"client 60", "worker 1440", "worker 60"},
+		},
+		{name: "cancelled bookings are not reminded", status: models.BookingStatusCancelled, startIn: 72 * time.Hour},
+		{name: "completed bookings are not reminded", status: models.BookingStatusCompleted, startIn: 72 * time.Hour},
+	}
+	for _, tt := range tests {
+		st := memory.New()
+		start := now.Add(tt.startIn).Truncate(time.Minute)
+		b := &models.Booking{ID: "b1", WorkerID: testWorkerID, ClientID: testClientID, Status: tt.status, StartTime: start, EndTime: start.Add(time.Hour)}
+
+		if tt.clientOffsets != nil {
+			if err := st.Reminders().SetOffsets(ctx, testClientID, tt.clientOffsets); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if tt.sentToClient != 0 {
+			sent := &models.BookingReminder{ID: "sent", BookingID: b.ID, UserID: testClientID, OffsetMinutes: tt.sentToClient, DueAt: start.Add(-time.Duration(tt.sentToClient) * time.Minute)}
+			if err := st.Reminders().ReplacePending(ctx, b.ID, []*models.BookingReminder{sent}); err != nil {
+				t.Fatal(err)
+			}
+			if err := st.Reminders().MarkSent(ctx, sent.ID, now); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := scheduleReminders(ctx, st, b); err != nil {
+			t.Fatalf("%s: scheduleReminders: %v", tt.name, err)
+		}
+		reminders, err := st.Reminders().ListByBooking(ctx, b.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, r := range reminders {
+			if r.SentAt != nil {
+				continue
+			}
+			party := "client"
+			if r.UserID == testWorkerID {
+				party = "worker"
+			}
+			if !r.DueAt.Equal(start.Add(-time.Duration(r.OffsetMinutes) * time.Minute)) {
+				t.Errorf("%s: reminder %d minutes ahead is due at %s", tt.name, r.OffsetMinutes, r.DueAt)
+			}
+			got = append(got, fmt.Sprintf("%s %d", party, r.OffsetMinutes))
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: pending reminders = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDispatchDueReminders(t *testing.T) {
+	ctx := context.Background()
+	s, st := newTestService(t)
+
+	start := daysAhead(7, 10)
+	booking, err := s.CreateBooking(ctx, testClientID, &models.CreateBookingRequest{WorkerID: testWorkerID, Title: "Tax return", StartTime: start, EndTime: start.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := s.CreateBooking(ctx, testClientID, &models.CreateBookingRequest{WorkerID: testWorkerID, Title: "Cancelled", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make one reminder of each booking due now, long after its one hour offset
+	due := time.Now().Add(-time.Minute)
+	for _, b := range []*models.Booking{booking, cancelled} {
+		r := &models.BookingReminder{ID: "due-" + b.ID, BookingID: b.ID, UserID: testClientID, OffsetMinutes: 60, DueAt: due}
+		if err := st.Reminders().ReplacePending(ctx, b.ID, []*models.BookingReminder{r}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Cancelled after its reminder was claimed, e.g. by a concurrent request
+	b, err := st.Bookings().Get(ctx, cancelled.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Status = models.BookingStatusCancelled
+	if err := st.Bookings().Update(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	sent, err := s.DispatchDueReminders(ctx)
+	if err != nil || sent != 1 {
+		t.Fatalf("DispatchDueReminders = %d, %v, want 1", sent, err)
+	}
+	if sent, _ := s.DispatchDueReminders(ctx); sent != 0 {
+		t.Fatalf("second dispatch sent %d reminders again", sent)
+	}
+
+	queued, err := st.Outbox().Claim(ctx, time.Now(), time.Now().Add(time.Minute), []string{events.BookingReminder}, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queued) != 1 || queued[0].AggregateID != booking.ID {
+		t.Fatalf("queued %d reminder events, want one for %s", len(queued), booking.ID)
+	}
+	var event struct {
+		Data models.ReminderNotification `json:"data"`
+	}
+	if err := json.Unmarshal(queued[0].Payload, &event); err != nil {
+		t.Fatal(err)
+	}
+	body := fmt.Sprintf("%q starts in %s", "Tax return", formatOffset(minutesLeft(time.Until(start))))
+	if n := event.Data; n.UserID != testClientID || n.Type != "booking_reminder" || n.Body != body || n.Data["bookingId"] != booking.ID || n.Data["offsetMinutes"] != float64(60) {
+		t.Fatalf("notification = %+v", n)
+	}
+
+	reminders, err := st.Reminders().ListByBooking(ctx, cancelled.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range reminders {
+		if r.ID == "due-"+cancelled.ID {
+			t.Fatalf("reminder of the cancelled booking was kept: %+v", r)
+		}
+	}
+}
+
+func TestMinutesLeft(t *testing.T) {
+	tests := []struct {
+		left time.Duration
+		want int
+	}{
+		{10 * time.Second, 1},
+		{59*time.Minute + 50*time.Second, 60},
+		{90 * time.Minute, 90},
+		{23*time.Hour + 58*time.Minute, 1440},
+		{25*time.Hour + 10*time.Minute, 1500},
+		{6*24*time.Hour + 14*time.Hour, 7 * 1440},
+	}
+	for _, tt := range tests {
+		if got := minutesLeft(tt.left); got != tt.want {
+			t.Errorf("minutesLeft(%s) = %d, want %d", tt.left, got, tt.want)
+		}
+	}
+}
+
+func TestFormatOffset(t *testing.T) {
+	tests := []struct {
+		minutes int
+		want    string
+	}{
+		{1, "1 minute"},
+		{90, "90 minutes"},
+		{60, "1 hour"},
+		{120, "2 hours"},
+		{1440, "1 day"},
+		{2 * 1440, "2 days"},
+		{1500, "25 hours"},
+	}
+	for _, tt := range tests {
+		if got := formatOffset(tt.minutes); got != tt.want {
+			t.Errorf("formatOffset(%d) = %q, want %q", tt.minutes, got, tt.want)
+		}
+	}
+}
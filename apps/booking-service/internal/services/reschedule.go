@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/events"
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRescheduleNotFound = apperr.NotFound("NOT_FOUND", "reschedule request not found")
+	ErrRescheduleClosed   = apperr.Conflict("RESCHEDULE_CLOSED", "reschedule request is no longer pending")
+)
+
+// ProposeReschedule records a proposal to move the booking. Any earlier pending proposal is
+// superseded. The counterparty must accept before the booking changes.
+func (s *BookingService) ProposeReschedule(ctx context.Context, bookingID string, userID string, req *models.ProposeRescheduleRequest) (*models.RescheduleRequest, error) {
+	booking, err := s.GetBookingByID(ctx, bookingID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.states.Apply(booking, ActionReschedule, userID); err != nil {
+		return nil, err
+	}
+
+	// Validate early so nobody is asked to accept a time that cannot work
+	slot := slotRequest{WorkerID: booking.WorkerID, Start: req.StartTime, End: req.EndTime, ExcludeBookingID: booking.ID, HolderID: booking.ClientID}
+	if err := ensureBookable(ctx, s.store, slot, time.Now()); err != nil {
+		return nil, err
+	}
+
+	proposal := &models.RescheduleRequest{
+		ID:         uuid.New().String(),
+		BookingID:  booking.ID,
+		ProposedBy: userID,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+		Reason:     req.Reason,
+		Status:     models.RescheduleStatusPending,
+		CreatedAt:  time.Now(),
+	}
+
+	err = s.store.WithTx(ctx, func(tx store.Store) error {
+		existing, err := tx.Reschedules().ListByBooking(ctx, booking.ID)
+		if err != nil {
+			return err
+		}
+		for _, r := range existing {
+			if r.Status != models.RescheduleStatusPending {
+				continue
+			}
+			r.Status = models.RescheduleStatusSuperseded
+			if err := tx.Reschedules().Update(ctx, r); err != nil {
+				return err
+			}
+		}
+
+		return tx.Reschedules().Create(ctx, proposal)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+func (s *BookingService) GetRescheduleRequests(ctx context.Context, bookingID string, userID string) ([]*models.RescheduleRequest, error) {
+	if _, err := s.GetBookingByID(ctx, bookingID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.store.Reschedules().ListByBooking(ctx, bookingID)
+}
+
+// AcceptReschedule moves the booking to the proposed time, re-validating it against availability
+// and conflicts and recalculating duration and total amount, all in one transaction
+func (s *BookingService) AcceptReschedule(ctx context.Context, bookingID string, requestID string, userID string) (*models.Booking, error) {
+	mc := &meetingChanges{}
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		booking, err := getBooking(ctx, tx, bookingID, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.LockWorker(ctx, booking.WorkerID); err != nil {
+			return err
+		}
+
+		proposal, err := getPendingReschedule(ctx, tx, bookingID, requestID)
+		if err != nil {
+			return err
+		}
+
+		if proposal.ProposedBy == userID {
+			return fmt.Errorf("%w: only the counterparty can accept a reschedule", ErrTransitionForbidden)
+		}
+
+		if _, err := s.states.Apply(booking, ActionReschedule, userID); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		slot := slotRequest{WorkerID: booking.WorkerID, Start: proposal.StartTime, End: proposal.EndTime, ExcludeBookingID: booking.ID, HolderID: booking.ClientID}
+		if err := ensureBookable(ctx, tx, slot, now); err != nil {
+			return err
+		}
+
+		before := *booking
+		booking.StartTime = proposal.StartTime
+		booking.EndTime = proposal.EndTime
+		booking.Duration = wholeMinutes(proposal.StartTime, proposal.EndTime)
+		// The new slot is priced on the worker's current rate card, like a new booking would be
+		settings, err := tx.Availability().Get(ctx, booking.WorkerID)
+		if err != nil {
+			return err
+		}
+		if err := priceBooking(settings, booking, now); err != nil {
+			return err
+		}
+		booking.SeriesException = booking.SeriesID != nil
+		booking.UpdatedAt = now
+		// The old link goes out with the old time
+		regenerateMeeting(booking, mc)
+
+		if err := tx.Bookings().Update(ctx, booking); err != nil {
+			if errors.Is(err, store.ErrOverlap) {
+				return ErrSlotUnavailable
+			}
+			return concurrent(err)
+		}
+		var reason string
+		if proposal.Reason != nil {
+			reason = *proposal.Reason
+		}
+		if err := recordBookingEvent(ctx, tx, &before, booking, userID, ActionReschedule, reason); err != nil {
+			return err
+		}
+		if err := scheduleReminders(ctx, tx, booking); err != nil {
+			return err
+		}
+
+		if err := respondToReschedule(ctx, tx, proposal, models.RescheduleStatusAccepted, userID, now); err != nil {
+			return err
+		}
+
+		return enqueueBookingEvent(ctx, tx, events.BookingRescheduled, booking.ID)
+	})
+	s.settleMeetings(ctx, mc, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetBookingByID(ctx, bookingID, userID)
+}
+
+// RejectReschedule declines a proposal. When the proposer calls it the proposal is withdrawn.
+func (s *BookingService) RejectReschedule(ctx context.Context, bookingID string, requestID string, userID string) (*models.RescheduleRequest, error) {
+	if _, err := s.GetBookingByID(ctx, bookingID, userID); err != nil {
+		return nil, err
+	}
+
+	var proposal *models.RescheduleRequest
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		proposal, err = getPendingReschedule(ctx, tx, bookingID, requestID)
+		if err != nil {
+			return err
+		}
+
+		status := models.RescheduleStatusRejected
+		if proposal.ProposedBy == userID {
+			status = models.RescheduleStatusWithdrawn
+		}
+
+		return respondToReschedule(ctx, tx, proposal, status, userID, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return proposal, nil
+}
+
+func getPendingReschedule(ctx context.Context, st store.Store, bookingID string, requestID string) (*models.RescheduleRequest, error) {
+	r, err := st.Reschedules().Get(ctx, requestID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrRescheduleNotFound
+		}
+		return nil, err
+	}
+
+	if r.BookingID != bookingID {
+		return nil, ErrRescheduleNotFound
+	}
+
+	if r.Status != models.RescheduleStatusPending {
+		return nil, ErrRescheduleClosed
+	}
+
+	return r, nil
+}
+
+func respondToReschedule(ctx context.Context, st store.Store, r *models.RescheduleRequest, status string, userID string, now time.Time) error {
+	r.Status = status
+	r.RespondedBy = &userID
+	r.RespondedAt = &now
+	return st.Reschedules().Update(ctx, r)
+}
@@ -0,0 +1,214 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"booking-service/internal/apperr"
+)
+
+// MaxSeriesOccurrences caps how many bookings a single recurrence rule may expand to
+const MaxSeriesOccurrences = 52
+
+var ErrInvalidRecurrence = apperr.Validation("VALIDATION_ERROR", "invalid recurrence rule")
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RecurrenceRule is the subset of an RFC 5545 RRULE the booking service supports:
+// FREQ (DAILY, WEEKLY, MONTHLY), INTERVAL, COUNT, UNTIL and BYDAY.
+type RecurrenceRule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    time.Time
+	ByDay    []time.Weekday
+}
+
+func ParseRecurrenceRule(rule string) (*RecurrenceRule, error) {
+	r := &RecurrenceRule{Interval: 1}
+
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRecurrence, part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRecurrence)
+			}
+			r.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRecurrence)
+			}
+			r.Count = n
+		case "UNTIL":
+			until, err := parseRRuleTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must be a date or UTC date-time", ErrInvalidRecurrence)
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := rruleWeekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, fmt.Errorf("%w: unsupported BYDAY value %q", ErrInvalidRecurrence, day)
+				}
+				r.ByDay = append(r.ByDay, weekday)
+			}
+		case "WKST":
+			// Weeks always start on Monday
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %q", ErrInvalidRecurrence, key)
+		}
+	}
+
+	switch r.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY":
+	default:
+		return nil, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY or MONTHLY", ErrInvalidRecurrence)
+	}
+
+	if r.Count == 0 && r.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT or UNTIL is required", ErrInvalidRecurrence)
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, fmt.Errorf("%w: COUNT and UNTIL cannot both be set", ErrInvalidRecurrence)
+	}
+	if r.Count > MaxSeriesOccurrences {
+		return nil, fmt.Errorf("%w: COUNT must not exceed %d", ErrInvalidRecurrence, MaxSeriesOccurrences)
+	}
+
+	sort.Slice(r.ByDay, func(i, j int) bool { return mondayIndex(r.ByDay[i]) < mondayIndex(r.ByDay[j]) })
+
+	return r, nil
+}
+
+func parseRRuleTime(value string) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+	// A bare date means the end of that day in UTC
+	t, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(24*time.Hour - time.Second), nil
+}
+
+// String renders the rule back into RRULE form
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, weekday := range r.ByDay {
+			for code, wd := range rruleWeekdays {
+				if wd == weekday {
+					days = append(days, code)
+				}
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Occurrences expands the rule from dtstart. Occurrences keep dtstart's wall-clock time in loc,
+// so a weekly 10:00 session stays at 10:00 across DST changes.
+func (r *RecurrenceRule) Occurrences(dtstart time.Time, loc *time.Location) ([]time.Time, error) {
+	local := dtstart.In(loc)
+	hour, minute := local.Hour(), local.Minute()
+
+	var occurrences []time.Time
+	add := func(year int, month time.Month, day int) bool {
+		t := wallClock(year, month, day, hour, minute, loc, false)
+		if t.Before(dtstart) {
+			return true
+		}
+		if !r.Until.IsZero() && t.After(r.Until) {
+			return false
+		}
+		occurrences = append(occurrences, t)
+		return r.Count == 0 || len(occurrences) < r.Count
+	}
+
+	// Bound the walk so a sparse rule or an UNTIL far in the future cannot loop for long
+	for period := 0; period < 5*366 && len(occurrences) <= MaxSeriesOccurrences; period++ {
+		var more bool
+		switch r.Freq {
+		case "DAILY":
+			d := local.AddDate(0, 0, period*r.Interval)
+			more = true
+			if len(r.ByDay) == 0 || slices.Contains(r.ByDay, d.Weekday()) {
+				more = add(d.Year(), d.Month(), d.Day())
+			}
+		case "WEEKLY":
+			weekStart := local.AddDate(0, 0, -mondayIndex(local.Weekday())+period*7*r.Interval)
+			days := r.ByDay
+			if len(days) == 0 {
+				days = []time.Weekday{local.Weekday()}
+			}
+			more = true
+			for _, weekday := range days {
+				d := weekStart.AddDate(0, 0, mondayIndex(weekday))
+				if more = add(d.Year(), d.Month(), d.Day()); !more {
+					break
+				}
+			}
+		case "MONTHLY":
+			first := time.Date(local.Year(), local.Month()+time.Month(period*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+			more = true
+			// Months without the start day (e.g. the 31st) are skipped, as RFC 5545 requires
+			if d := first.AddDate(0, 0, local.Day()-1); d.Month() == first.Month() {
+				more = add(d.Year(), d.Month(), d.Day())
+			}
+		}
+		if !more {
+			break
+		}
+	}
+
+	if len(occurrences) > MaxSeriesOccurrences {
+		return nil, fmt.Errorf("%w: rule expands to more than %d occurrences", ErrInvalidRecurrence, MaxSeriesOccurrences)
+	}
+	if len(occurrences) == 0 {
+		return nil, fmt.Errorf("%w: rule produces no occurrences", ErrInvalidRecurrence)
+	}
+
+	return occurrences, nil
+}
+
+func mondayIndex(d time.Weekday) int {
+	return (int(d) + 6) % 7
+}
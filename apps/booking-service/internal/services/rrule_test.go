@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseRecurrenceRule(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+		err  bool
+	}{
+		{rule: "FREQ=WEEKLY;COUNT=4", want: "FREQ=WEEKLY;COUNT=4"},
+		{rule: "RRULE:freq=daily;interval=2;count=3", want: "FREQ=DAILY;INTERVAL=2;COUNT=3"},
+		{rule: "FREQ=WEEKLY;BYDAY=FR,MO,WE;COUNT=6;WKST=MO", want: "FREQ=WEEKLY;COUNT=6;BYDAY=MO,WE,FR"},
+		{rule: "FREQ=MONTHLY;UNTIL=20261231", want: "FREQ=MONTHLY;UNTIL=20261231T235959Z"},
+		{rule: "FREQ=DAILY;UNTIL=20261231T100000Z", want: "FREQ=DAILY;UNTIL=20261231T100000Z"},
+		{rule: "FREQ=YEARLY;COUNT=2", err: true},
+		{rule: "FREQ=WEEKLY", err: true},
+		{rule: "FREQ=WEEKLY;COUNT=2;UNTIL=20261231", err: true},
+		{rule: "FREQ=WEEKLY;COUNT=53", err: true},
+		{rule: "FREQ=WEEKLY;COUNT=0", err: true},
+		{rule: "FREQ=WEEKLY;INTERVAL=-1;COUNT=2", err: true},
+		{rule: "FREQ=WEEKLY;BYDAY=1MO;COUNT=2", err: true},
+		{rule: "FREQ=WEEKLY;BYMONTH=1;COUNT=2", err: true},
+		{rule: "FREQ=WEEKLY;COUNT", err: true},
+		{rule: "FREQ=DAILY;UNTIL=2026-12-31", err: true},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRecurrenceRule(tt.rule)
+		if tt.err {
+			if !errors.Is(err, ErrInvalidRecurrence) {
+				t.Errorf("ParseRecurrenceRule(%q) error = %v, want ErrInvalidRecurrence", tt.rule, err)
+			}
+			continue
+		}
+		if err != nil || rule.String() != tt.want {
+			t.Errorf("ParseRecurrenceRule(%q) = %v, %v, want %s", tt.rule, rule, err, tt.want)
+		}
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	newYork := mustLoadLocation(t, "America/New_York")
+	// 2026-10-14 is a Wednesday
+	wed := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rule    string
+		dtstart time.Time
+		loc     *time.Location
+		want    []string
+	}{
+		{
+			name: "daily with interval", rule: "FREQ=DAILY;INTERVAL=2;COUNT=3", dtstart: wed, loc: time.UTC,
+			want: []string{"2026-10-14T10:00:00Z", "2026-10-16T10:00:00Z", "2026-10-18T10:00:00Z"},
+		},
+		{
+			name: "daily on weekdays only", rule: "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR;COUNT=4", dtstart: wed, loc: time.UTC,
+			want: []string{"2026-10-14T10:00:00Z", "2026-10-15T10:00:00Z", "2026-10-16T10:00:00Z", "2026-10-19T10:00:00Z"},
+		},
+		{
+			name: "weekly by day skips days before dtstart", rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=4", dtstart: wed, loc: time.UTC,
+			want: []string{"2026-10-14T10:00:00Z", "2026-10-16T10:00:00Z", "2026-10-19T10:00:00Z", "2026-10-21T10:00:00Z"},
+		},
+		{
+			name: "fortnightly until a date", rule: "FREQ=WEEKLY;INTERVAL=2;UNTIL=20261111", dtstart: wed, loc: time.UTC,
+			want: []string{"2026-10-14T10:00:00Z", "2026-10-28T10:00:00Z", "2026-11-11T10:00:00Z"},
+		},
+		{
+			name: "monthly skips months without the day", rule: "FREQ=MONTHLY;COUNT=3", dtstart: time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC), loc: time.UTC,
+			want: []string{"2026-01-31T09:00:00Z", "2026-03-31T09:00:00Z", "2026-05-31T09:00:00Z"},
+		},
+		{
+			// Berlin leaves summer time on 2026-10-25, so the UTC time moves an hour later
+			name: "weekly keeps the wall clock across DST", rule: "FREQ=WEEKLY;COUNT=3", dtstart: time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC), loc: berlin,
+			want: []string{"2026-10-17T10:00:00+02:00", "2026-10-24T10:00:00+02:00", "2026-10-31T10:00:00+01:00"},
+		},
+		{
+			// 02:30 does not exist in New York on 2026-03-08, so that occurrence moves to the end of the gap
+			name: "daily into a DST gap", rule: "FREQ=DAILY;COUNT=3", dtstart: time.Date(2026, 3, 7, 7, 30, 0, 0, time.UTC), loc: newYork,
+			want: []string{"2026-03-07T02:30:00-05:00", "2026-03-08T03:00:00-04:00", "2026-03-09T02:30:00-04:00"},
+		},
+		{
+			// 01:30 happens twice in New York on 2026-11-01; the series takes the first one
+			name: "daily into a DST overlap", rule: "FREQ=DAILY;COUNT=3", dtstart: time.Date(2026, 10, 31, 5, 30, 0, 0, time.UTC), loc: newYork,
+			want: []string{"2026-10-31T01:30:00-04:00", "2026-11-01T01:30:00-04:00", "2026-11-02T01:30:00-05:00"},
+		},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRecurrenceRule(tt.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		starts, err := rule.Occurrences(tt.dtstart, tt.loc)
+		if err != nil {
+			t.Errorf("%s: Occurrences: %v", tt.name, err)
+			continue
+		}
+		got := make([]string, len(starts))
+		for i, start := range starts {
+			got[i] = start.In(tt.loc).Format(time.RFC3339)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: Occurrences = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOccurrencesLimits(t *testing.T) {
+	wed := time.Date(2026, 10, 14, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		rule string
+	}{
+		{name: "too many occurrences", rule: "FREQ=DAILY;UNTIL=20271231"},
+		{name: "no occurrences", rule: "FREQ=DAILY;UNTIL=20261001"},
+	}
+	for _, tt := range tests {
+		rule, err := ParseRecurrenceRule(tt.rule)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if _, err := rule.Occurrences(wed, time.UTC); !errors.Is(err, ErrInvalidRecurrence) {
+			t.Errorf("%s: Occurrences error = %v, want ErrInvalidRecurrence", tt.name, err)
+		}
+	}
+}
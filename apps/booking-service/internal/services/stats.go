@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"booking-service/internal/apperr"
+	"booking-service/internal/models"
+	"booking-service/internal/money"
+	"booking-service/internal/store"
+)
+
+const (
+	// MaxStatsRangeDays caps the period a stats report may cover
+	MaxStatsRangeDays = 366
+	// defaultStatsDays is the period reported on when none is given, ending today
+	defaultStatsDays = 30
+)
+
+var (
+	ErrInvalidTimezone    = apperr.BadRequest("INVALID_TIMEZONE", "Invalid timezone")
+	ErrStatsRangeTooLarge = apperr.BadRequest("INVALID_DATE_RANGE", fmt.Sprintf("date range must not exceed %d days", MaxStatsRangeDays))
+)
+
+// bookedStatuses are the statuses of bookings that took up the worker's time
+var bookedStatuses = []string{
+	models.BookingStatusConfirmed,
+	models.BookingStatusInProgress,
+	models.BookingStatusAwaitingCompletion,
+	models.BookingStatusCompleted,
+	models.BookingStatusNoShow,
+}
+
+// statsTally accumulates the bookings and availability of one group
+type statsTally struct {
+	byStatus  map[string]int
+	bookings  int
+	booked    time.Duration
+	available time.Duration
+	leadTime  time.Duration
+	gross     map[string]money.Amount
+}
+
+func newStatsTally() *statsTally {
+	return &statsTally{byStatus: map[string]int{}, gross: map[string]money.Amount{}}
+}
+
+func (t *statsTally) add(b *models.Booking) {
+	t.bookings++
+	t.byStatus[b.Status]++
+	t.leadTime += b.StartTime.Sub(b.CreatedAt)
+
+	if slices.Contains(bookedStatuses, b.Status) {
+		t.booked += b.EndTime.Sub(b.StartTime)
+	}
+
+	// Gross is what the client pays: bookings that went ahead or still will, and whatever a
+	// cancellation didn't refund. Who turned up to a no-show is disputed, so it isn't counted.
+	switch {
+	case b.Status == models.BookingStatusCancelled && b.Cancellation != nil:
+		t.gross[b.Currency] = t.gross[b.Currency].Add(b.Cancellation.ClientPenalty)
+	case slices.Contains(bookedStatuses, b.Status) && b.Status != models.BookingStatusNoShow:
+		t.gross[b.Currency] = t.gross[b.Currency].Add(b.TotalAmount)
+	}
+}
+
+func (t *statsTally) stats(withAvailability bool) models.BookingStats {
+	stats := models.BookingStats{
+		Bookings:         t.bookings,
+		ByStatus:         t.byStatus,
+		BookedHours:      roundTo(t.booked.Hours(), 2),
+		GrossAmount:      t.gross,
+		CancellationRate: ratio(t.byStatus[models.BookingStatusCancelled], t.bookings),
+		// Only sessions that were due to happen could have been missed
+		NoShowRate: ratio(t.byStatus[models.BookingStatusNoShow], t.byStatus[models.BookingStatusNoShow]+t.byStatus[models.BookingStatusCompleted]),
+	}
+	if t.bookings > 0 {
+		lead := roundTo(t.leadTime.Hours()/float64(t.bookings), 2)
+		stats.AverageLeadTimeHours = &lead
+	}
+	if withAvailability {
+		available := roundTo(t.available.Hours(), 2)
+		stats.AvailableHours = &available
+		if t.available > 0 {
+			utilisation := roundTo(t.booked.Hours()/t.available.Hours(), 4)
+			stats.Utilisation = &utilisation
+		}
+	}
+	return stats
+}
+
+func ratio(n, total int) *float64 {
+	if total == 0 {
+		return nil
+	}
+	r := roundTo(float64(n)/float64(total), 4)
+	return &r
+}
+
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow10(places)
+	return math.Round(value*scale) / scale
+}
+
+// GetBookingStats reports on the user's bookings as a worker, or as a client, that start between
+// the query's dates, in total and grouped by day, week (from Monday) or month. Workers also see
+// the hours their weekly availability offered, less the slots they blocked, and how much of them
+// was booked.
+func (s *BookingService) GetBookingStats(ctx context.Context, userID string, req *models.BookingStatsQuery) (*models.BookingStatsReport, error) {
+	role := req.Role
+	if role == "" {
+		role = string(PartyWorker)
+	}
+	groupBy := req.GroupBy
+	if groupBy == "" {
+		groupBy = "day"
+	}
+	isWorker := role == string(PartyWorker)
+
+	var schedule *workerSchedule
+	loc := time.UTC
+	if isWorker {
+		var err error
+		if schedule, err = loadWorkerSchedule(ctx, s.store, userID); err != nil {
+			return nil, err
+		}
+		loc = schedule.Location
+	}
+	if req.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(req.Timezone); err != nil {
+			return nil, ErrInvalidTimezone
+		}
+	}
+
+	// Dates are taken as calendar dates in loc
+	from, to := req.From, req.To
+	if to.IsZero() {
+		now := time.Now().In(loc)
+		to = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -(defaultStatsDays - 1))
+	}
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if to.Before(from) {
+		return nil, ErrInvalidDateRange
+	}
+	if int(to.Sub(from).Hours()/24)+1 > MaxStatsRangeDays {
+		return nil, ErrStatsRangeTooLarge
+	}
+
+	// One tally per group, and the group each day falls in
+	var groups []*models.BookingStatsGroup
+	var tallies []*statsTally
+	groupOf := map[string]int{}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if len(groups) == 0 || !groupStart(day, groupBy).Equal(groupStart(day.AddDate(0, 0, -1), groupBy)) {
+			groups = append(groups, &models.BookingStatsGroup{From: date})
+			tallies = append(tallies, newStatsTally())
+		}
+		groups[len(groups)-1].To = date
+		groupOf[date] = len(groups) - 1
+	}
+
+	start := dayBounds(from.Year(), from.Month(), from.Day(), loc).Start
+	end := dayBounds(to.Year(), to.Month(), to.Day(), loc).End
+
+	bookings, err := s.store.Bookings().List(ctx, store.BookingQuery{UserID: userID, Role: role, StartsFrom: start, StartsBefore: end})
+	if err != nil {
+		return nil, err
+	}
+	totals := newStatsTally()
+	for _, b := range bookings {
+		i, ok := groupOf[b.StartTime.In(loc).Format("2006-01-02")]
+		if !ok {
+			continue
+		}
+		tallies[i].add(b)
+		totals.add(b)
+	}
+
+	if isWorker {
+		blockedSlots, err := s.store.BlockedSlots().ListOverlapping(ctx, userID, start, end)
+		if err != nil {
+			return nil, err
+		}
+		blocked := make([]timeRange, 0, len(blockedSlots))
+		for _, b := range blockedSlots {
+			blocked = append(blocked, timeRange{Start: b.StartTime, End: b.EndTime})
+		}
+		blocked = mergeRanges(blocked)
+
+		for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+			hours := availableTime(schedule, dayBounds(day.Year(), day.Month(), day.Day(), loc), blocked)
+			tallies[groupOf[day.Format("2006-01-02")]].available += hours
+			totals.available += hours
+		}
+	}
+
+	for i, group := range groups {
+		group.BookingStats = tallies[i].stats(isWorker)
+	}
+
+	return &models.BookingStatsReport{
+		Role:     role,
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Timezone: loc.String(),
+		GroupBy:  groupBy,
+		Totals:   totals.stats(isWorker),
+		Groups:   groups,
+	}, nil
+}
+
+// groupStart is the first day of the group the calendar date falls in
+func groupStart(day time.Time, groupBy string) time.Time {
+	switch groupBy {
+	case "week":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// availableTime is how long the worker's weekly availability offers within the span, less the
+// blocked ranges, which must not overlap each other. Windows are taken on the worker's own
+// calendar, so a span that is a day somewhere else gets the parts of the worker's days it covers.
+func availableTime(schedule *workerSchedule, span timeRange, blocked []timeRange) time.Duration {
+	first, last := span.Start.In(schedule.Location), span.End.In(schedule.Location)
+	lastDay := time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, time.UTC)
+	var windows []timeRange
+	for day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC); !day.After(lastDay); day = day.AddDate(0, 0, 1) {
+		windows = append(windows, schedule.windowsOn(day.Year(), day.Month(), day.Day())...)
+	}
+
+	var total time.Duration
+	for _, window := range mergeRanges(windows) {
+		window.Start, window.End = later(window.Start, span.Start), earlier(window.End, span.End)
+		if !window.End.After(window.Start) {
+			continue
+		}
+		total += window.End.Sub(window.Start)
+		for _, b := range blocked {
+			if start, end := later(b.Start, window.Start), earlier(b.End, window.End); end.After(start) {
+				total -= end.Sub(start)
+			}
+		}
+	}
+	return total
+}
+
+// mergeRanges sorts the ranges and joins those that overlap or touch
+func mergeRanges(ranges []timeRange) []timeRange {
+	slices.SortFunc(ranges, func(a, b timeRange) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	var merged []timeRange
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && !r.Start.After(merged[n-1].End) {
+			merged[n-1].End = later(merged[n-1].End, r.End)
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func earlier(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
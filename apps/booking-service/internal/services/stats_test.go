@@ -0,0 +1,100 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"booking-service/internal/models"
+)
+
+func TestGroupStart(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		day     time.Time
+		groupBy string
+		want    time.Time
+	}{
+		{day: date(time.October, 16), groupBy: "day", want: date(time.October, 16)},
+		{day: date(time.October, 16), groupBy: "week", want: date(time.October, 12)},
+		{day: date(time.October, 12), groupBy: "week", want: date(time.October, 12)},
+		{day: date(time.October, 18), groupBy: "week", want: date(time.October, 12)},
+		{day: date(time.November, 1), groupBy: "week", want: date(time.October, 26)},
+		{day: date(time.October, 16), groupBy: "month", want: date(time.October, 1)},
+		{day: date(time.October, 31), groupBy: "month", want: date(time.October, 1)},
+	}
+	for _, tt := range tests {
+		if got := groupStart(tt.day, tt.groupBy); !got.Equal(tt.want) {
+			t.Errorf("groupStart(%s, %s) = %s, want %s", tt.day.Format(time.DateOnly), tt.groupBy, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestAvailableTime(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	newYork := mustLoadLocation(t, "America/New_York")
+	monday := time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+	at := func(hour int) time.Time {
+		return time.Date(2026, time.October, 19, hour, 0, 0, 0, berlin)
+	}
+	slot := func(weekday time.Weekday, start, end string) models.AvailabilitySlot {
+		return models.AvailabilitySlot{DayOfWeek: int(weekday), StartTime: start, EndTime: end, IsRecurring: true}
+	}
+
+	tests := []struct {
+		name  string
+		slots []models.AvailabilitySlot
+		day   time.Time
+		// viewer is the timezone day is a date in, the worker's own by default
+		viewer  *time.Location
+		blocked []timeRange
+		want    time.Duration
+	}{
+		{name: "one window", slots: []models.AvailabilitySlot{slot(time.Monday, "09:00", "17:00")}, day: monday, want: 8 * time.Hour},
+		{name: "overlapping windows count once", slots: []models.AvailabilitySlot{slot(time.Monday, "09:00", "12:00"), slot(time.Monday, "11:00", "17:00")}, day: monday, want: 8 * time.Hour},
+		{name: "separate windows", slots: []models.AvailabilitySlot{slot(time.Monday, "09:00", "12:00"), slot(time.Monday, "14:00", "17:00")}, day: monday, want: 6 * time.Hour},
+		{name: "other days", slots: []models.AvailabilitySlot{slot(time.Tuesday, "09:00", "17:00")}, day: monday},
+		{
+			name: "blocked inside the window", slots: []models.AvailabilitySlot{slot(time.Monday, "09:00", "17:00")}, day: monday,
+			blocked: []timeRange{{Start: at(13), End: at(14)}}, want: 7 * time.Hour,
+		},
+		{
+			name: "blocked across the window's start", slots: []models.AvailabilitySlot{slot(time.Monday, "09:00", "17:00")}, day: monday,
+			blocked: []timeRange{{Start: at(8), End: at(10)}}, want: 7 * time.Hour,
+		},
+		{
+			name: "blocked across two windows", slots: []models.AvailabilitySlot{slot(time.Monday, "09:00", "12:00"), slot(time.Monday, "13:00", "17:00")}, day: monday,
+			blocked: []timeRange{{Start: at(11), End: at(14)}}, want: 5 * time.Hour,
+		},
+		{
+			name: "blocked outside the window", slots: []models.AvailabilitySlot{slot(time.Monday, "09:00", "17:00")}, day: monday,
+			blocked: []timeRange{{Start: at(17), End: at(19)}}, want: 8 * time.Hour,
+		},
+		{
+			name: "whole day on the spring DST change", slots: []models.AvailabilitySlot{slot(time.Sunday, "00:00", "24:00")},
+			day: time.Date(2026, time.March, 29, 0, 0, 0, 0, time.UTC), want: 23 * time.Hour,
+		},
+		{
+			// Monday in New York runs from 06:00 on Monday to 06:00 on Tuesday in Berlin
+			name: "a day in another timezone", slots: []models.AvailabilitySlot{slot(time.Monday, "09:00", "17:00"), slot(time.Tuesday, "00:00", "08:00")},
+			day: monday, viewer: newYork, want: 14 * time.Hour,
+		},
+		{
+			name: "blocked in another timezone", slots: []models.AvailabilitySlot{slot(time.Monday, "09:00", "17:00"), slot(time.Tuesday, "00:00", "08:00")},
+			day: monday, viewer: newYork, blocked: []timeRange{{Start: at(16), End: at(26)}}, want: 11 * time.Hour,
+		},
+	}
+	for _, tt := range tests {
+		schedule := &workerSchedule{Availability: tt.slots, Location: berlin, Rules: DefaultSchedulingRules()}
+		viewer := berlin
+		if tt.viewer != nil {
+			viewer = tt.viewer
+		}
+		span := dayBounds(tt.day.Year(), tt.day.Month(), tt.day.Day(), viewer)
+		if got := availableTime(schedule, span, tt.blocked); got != tt.want {
+			t.Errorf("%s: availableTime = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+type availability struct {
+	s *Store
+}
+
+func (r availability) Get(ctx context.Context, workerID string) (*store.WorkerSettings, error) {
+	defer r.s.lock()()
+
+	settings := r.s.data.workers[workerID]
+	settings.Availability = slices.Clone(settings.Availability)
+	return &settings, nil
+}
+
+func (r availability) update(workerID string, fn func(settings *store.WorkerSettings)) {
+	settings := r.s.data.workers[workerID]
+	fn(&settings)
+	r.s.data.workers[workerID] = settings
+}
+
+func (r availability) SetAvailability(ctx context.Context, workerID string, slots []models.AvailabilitySlot) error {
+	defer r.s.lock()()
+
+	r.update(workerID, func(settings *store.WorkerSettings) {
+		settings.Availability = slices.Clone(slots)
+	})
+	return nil
+}
+
+func (r availability) SetSchedulingRules(ctx context.Context, workerID string, rules *models.SchedulingRules) error {
+	defer r.s.lock()()
+
+	rulesJSON, err := json.Marshal(rules)
+	if err != nil {
+		return err
+	}
+	r.update(workerID, func(settings *store.WorkerSettings) {
+		settings.SchedulingRules = rulesJSON
+	})
+	return nil
+}
+
+func (r availability) SetCancellationPolicy(ctx context.Context, workerID string, policy *models.CancellationPolicy) error {
+	defer r.s.lock()()
+
+	stored := *policy
+	stored.Tiers = slices.Clone(policy.Tiers)
+	r.update(workerID, func(settings *store.WorkerSettings) {
+		settings.CancellationPolicy = &stored
+	})
+	return nil
+}
+
+func (r availability) SetRateCard(ctx context.Context, workerID string, card *models.RateCard) error {
+	defer r.s.lock()()
+
+	stored := *card
+	r.update(workerID, func(settings *store.WorkerSettings) {
+		settings.RateCard = &stored
+	})
+	return nil
+}
+
+type blockedSlots struct {
+	s *Store
+}
+
+func (r blockedSlots) Create(ctx context.Context, workerID string, slot *models.BlockedSlot) error {
+	defer r.s.lock()()
+
+	r.s.data.blockedSlots[slot.ID] = blockedSlot{workerID: workerID, slot: *slot}
+	return nil
+}
+
+func (r blockedSlots) Delete(ctx context.Context, workerID string, id string) error {
+	defer r.s.lock()()
+
+	if stored, ok := r.s.data.blockedSlots[id]; ok && stored.workerID == workerID {
+		delete(r.s.data.blockedSlots, id)
+	}
+	return nil
+}
+
+func (r blockedSlots) ListOverlapping(ctx context.Context, workerID string, from, to time.Time) ([]*models.BlockedSlot, error) {
+	defer r.s.lock()()
+
+	list := make([]*models.BlockedSlot, 0)
+	for _, stored := range r.s.data.blockedSlots {
+		slot := stored.slot
+		if stored.workerID == workerID && slot.StartTime.Before(to) && slot.EndTime.After(from) {
+			list = append(list, &slot)
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,249 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+type bookings struct {
+	s *Store
+}
+
+func holdsSlot(b *models.Booking) bool {
+	return b.Status != models.BookingStatusCancelled && b.Status != models.BookingStatusDeclined && b.Status != models.BookingStatusExpired
+}
+
+// checkOverlap mirrors the bookings_no_overlap exclusion constraint
+func (r bookings) checkOverlap(b *models.Booking) error {
+	if !holdsSlot(b) {
+		return nil
+	}
+	for _, other := range r.s.data.bookings {
+		if other.ID != b.ID && other.WorkerID == b.WorkerID && holdsSlot(&other) &&
+			b.StartTime.Before(other.EndTime) && b.EndTime.After(other.StartTime) {
+			return store.ErrOverlap
+		}
+	}
+	return nil
+}
+
+// copyBooking copies b along with everything it points to, so the store shares nothing with callers
+func copyBooking(b models.Booking) models.Booking {
+	b.ProjectID = copyPtr(b.ProjectID)
+	b.SeriesID = copyPtr(b.SeriesID)
+	b.MeetingURL = copyPtr(b.MeetingURL)
+	b.Notes = copyPtr(b.Notes)
+	b.Cancellation = copyPtr(b.Cancellation)
+	if b.PriceBreakdown != nil {
+		breakdown := *b.PriceBreakdown
+		breakdown.Lines = slices.Clone(breakdown.Lines)
+		b.PriceBreakdown = &breakdown
+	}
+	if b.CancellationPolicy != nil {
+		policy := *b.CancellationPolicy
+		policy.Tiers = slices.Clone(policy.Tiers)
+		b.CancellationPolicy = &policy
+	}
+	if b.Worker != nil {
+		worker := *b.Worker
+		worker.AvatarUrl = copyPtr(worker.AvatarUrl)
+		b.Worker = &worker
+	}
+	if b.Client != nil {
+		client := *b.Client
+		client.AvatarUrl = copyPtr(client.AvatarUrl)
+		b.Client = &client
+	}
+	return b
+}
+
+func copyPtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func (r bookings) Create(ctx context.Context, b *models.Booking) error {
+	defer r.s.lock()()
+
+	if err := r.checkOverlap(b); err != nil {
+		return err
+	}
+	b.Version = 1
+	r.s.data.bookings[b.ID] = copyBooking(*b)
+	return nil
+}
+
+func (r bookings) Get(ctx context.Context, id string) (*models.Booking, error) {
+	defer r.s.lock()()
+
+	b, ok := r.s.data.bookings[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	b = copyBooking(b)
+	return &b, nil
+}
+
+func (r bookings) Update(ctx context.Context, b *models.Booking) error {
+	return r.update(b, "")
+}
+
+func (r bookings) Transition(ctx context.Context, b *models.Booking, from string) error {
+	return r.update(b, from)
+}
+
+func (r bookings) update(b *models.Booking, from string) error {
+	defer r.s.lock()()
+
+	current, ok := r.s.data.bookings[b.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	if current.Version != b.Version || (from != "" && current.Status != from) {
+		return store.ErrConflict
+	}
+	if err := r.checkOverlap(b); err != nil {
+		return err
+	}
+
+	// Only the fields the Postgres store updates may change
+	current.SeriesID = b.SeriesID
+	current.SeriesException = b.SeriesException
+	current.Title = b.Title
+	current.Description = b.Description
+	current.StartTime = b.StartTime
+	current.EndTime = b.EndTime
+	current.Duration = b.Duration
+	current.HourlyRate = b.HourlyRate
+	current.TotalAmount = b.TotalAmount
+	current.Currency = b.Currency
+	current.PriceBreakdown = b.PriceBreakdown
+	current.Status = b.Status
+	current.IsRemote = b.IsRemote
+	current.MeetingURL = b.MeetingURL
+	current.Notes = b.Notes
+	current.Cancellation = b.Cancellation
+	current.UpdatedAt = b.UpdatedAt
+	current.Version++
+	r.s.data.bookings[b.ID] = copyBooking(current)
+	b.Version = current.Version
+	return nil
+}
+
+func (r bookings) filter(keep func(b *models.Booking) bool) []*models.Booking {
+	list := make([]*models.Booking, 0)
+	for _, b := range r.s.data.bookings {
+		if keep(&b) {
+			b = copyBooking(b)
+			list = append(list, &b)
+		}
+	}
+	slices.SortFunc(list, func(a, b *models.Booking) int {
+		return a.StartTime.Compare(b.StartTime)
+	})
+	return list
+}
+
+func (r bookings) List(ctx context.Context, q store.BookingQuery) ([]*models.Booking, error) {
+	defer r.s.lock()()
+
+	sortValue := func(b *models.Booking) time.Time {
+		if q.SortField == store.SortCreatedAt {
+			return b.CreatedAt
+		}
+		return b.StartTime
+	}
+	compare := func(a, b *models.Booking) int {
+		c := sortValue(a).Compare(sortValue(b))
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if q.Descending {
+			return -c
+		}
+		return c
+	}
+
+	list := r.filter(func(b *models.Booking) bool {
+		userID, counterpartyID := b.ClientID, b.WorkerID
+		if q.Role == "worker" {
+			userID, counterpartyID = b.WorkerID, b.ClientID
+		}
+		switch {
+		case q.UserID != "" && userID != q.UserID,
+			len(q.Statuses) > 0 && !slices.Contains(q.Statuses, b.Status),
+			!q.StartsFrom.IsZero() && b.StartTime.Before(q.StartsFrom),
+			!q.StartsBefore.IsZero() && !b.StartTime.Before(q.StartsBefore),
+			!q.EndsAfter.IsZero() && !b.EndTime.After(q.EndsAfter),
+			!q.EndedBy.IsZero() && b.EndTime.After(q.EndedBy),
+			q.CounterpartyID != "" && counterpartyID != q.CounterpartyID,
+			q.WorkerID != "" && b.WorkerID != q.WorkerID,
+			q.ClientID != "" && b.ClientID != q.ClientID,
+			q.ProjectID != "" && (b.ProjectID == nil || *b.ProjectID != q.ProjectID),
+			q.MissingMeeting && (!b.IsRemote || b.MeetingURL != nil):
+			return false
+		}
+		if q.After != nil {
+			return compare(b, &models.Booking{ID: q.After.ID, StartTime: q.After.Value, CreatedAt: q.After.Value}) > 0
+		}
+		return true
+	})
+
+	slices.SortFunc(list, compare)
+	if q.Limit > 0 && len(list) > q.Limit {
+		list = list[:q.Limit]
+	}
+	return list, nil
+}
+
+func (r bookings) ListBySeries(ctx context.Context, seriesID string) ([]*models.Booking, error) {
+	defer r.s.lock()()
+
+	return r.filter(func(b *models.Booking) bool {
+		return b.SeriesID != nil && *b.SeriesID == seriesID
+	}), nil
+}
+
+func (r bookings) ListActive(ctx context.Context, workerID string, from, to time.Time) ([]*models.Booking, error) {
+	defer r.s.lock()()
+
+	return r.filter(func(b *models.Booking) bool {
+		return b.WorkerID == workerID && holdsSlot(b) && b.StartTime.Before(to) && b.EndTime.After(from)
+	}), nil
+}
+
+func (r bookings) ListDue(ctx context.Context, status string, field string, cutoff time.Time, limit int) ([]*models.Booking, error) {
+	defer r.s.lock()()
+
+	value := func(b *models.Booking) time.Time {
+		switch field {
+		case store.DueCreatedAt:
+			return b.CreatedAt
+		case store.DueEndTime:
+			return b.EndTime
+		}
+		return b.StartTime
+	}
+
+	list := r.filter(func(b *models.Booking) bool {
+		return b.Status == status && !value(b).After(cutoff)
+	})
+	slices.SortFunc(list, func(a, b *models.Booking) int {
+		if c := value(a).Compare(value(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+const (
+	workerID = "11111111-1111-1111-1111-111111111111"
+	clientID = "22222222-2222-2222-2222-222222222222"
+)
+
+var base = time.Date(2026, 10, 20, 9, 0, 0, 0, time.UTC)
+
+func booking(id string, hour int, status string) *models.Booking {
+	start := base.Add(time.Duration(hour) * time.Hour)
+	return &models.Booking{ID: id, WorkerID: workerID, ClientID: clientID, Status: status, StartTime: start, EndTime: start.Add(time.Hour), CreatedAt: base}
+}
+
+func TestBookingWrites(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		write func(st *Store) error
+		err   error
+	}{
+		{
+			name:  "overlapping bookings",
+			write: func(st *Store) error { return st.Bookings().Create(ctx, booking("b2", 0, models.BookingStatusPending)) },
+			err:   store.ErrOverlap,
+		},
+		{
+			name:  "back to back bookings",
+			write: func(st *Store) error { return st.Bookings().Create(ctx, booking("b2", 1, models.BookingStatusPending)) },
+		},
+		{
+			name: "cancelled bookings free their slot",
+			write: func(st *Store) error {
+				return st.Bookings().Create(ctx, booking("b2", 0, models.BookingStatusCancelled))
+			},
+		},
+		{
+			name: "current version",
+			write: func(st *Store) error {
+				b, _ := st.Bookings().Get(ctx, "b1")
+				return st.Bookings().Update(ctx, b)
+			},
+		},
+		{
+			name: "stale version",
+			write: func(st *Store) error {
+				b, _ := st.Bookings().Get(ctx, "b1")
+				if err := st.Bookings().Update(ctx, b); err != nil {
+					return err
+				}
+				b.Version--
+				return st.Bookings().Update(ctx, b)
+			},
+			err: store.ErrConflict,
+		},
+		{
+			name: "transition from the current status",
+			write: func(st *Store) error {
+				b, _ := st.Bookings().Get(ctx, "b1")
+				b.Status = models.BookingStatusConfirmed
+				return st.Bookings().Transition(ctx, b, models.BookingStatusPending)
+			},
+		},
+		{
+			name: "transition from another status",
+			write: func(st *Store) error {
+				b, _ := st.Bookings().Get(ctx, "b1")
+				b.Status = models.BookingStatusCompleted
+				return st.Bookings().Transition(ctx, b, models.BookingStatusConfirmed)
+			},
+			err: store.ErrConflict,
+		},
+		{
+			name:  "missing booking",
+			write: func(st *Store) error { return st.Bookings().Update(ctx, booking("b9", 5, models.BookingStatusPending)) },
+			err:   store.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		st := New()
+		if err := st.Bookings().Create(ctx, booking("b1", 0, models.BookingStatusPending)); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := tt.write(st); !errors.Is(err, tt.err) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.err)
+		}
+	}
+}
+
+func TestBookingList(t *testing.T) {
+	ctx := context.Background()
+	st := New()
+
+	url := "https://meet.example.test/b3"
+	remote := booking("b2", 1, models.BookingStatusConfirmed)
+	remote.IsRemote = true
+	linked := booking("b3", 2, models.BookingStatusConfirmed)
+	linked.IsRemote, linked.MeetingURL = true, &url
+	for _, b := range []*models.Booking{booking("b1", 0, models.BookingStatusPending), remote, linked, booking("b4", 3, models.BookingStatusCancelled)} {
+		if err := st.Bookings().Create(ctx, b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		q    store.BookingQuery
+		want []string
+	}{
+		{name: "everything", want: []string{"b1", "b2", "b3", "b4"}},
+		{name: "latest first", q: store.BookingQuery{Descending: true}, want: []string{"b4", "b3", "b2", "b1"}},
+		{name: "statuses", q: store.BookingQuery{Statuses: []string{models.BookingStatusConfirmed}}, want: []string{"b2", "b3"}},
+		{name: "missing meeting", q: store.BookingQuery{MissingMeeting: true}, want: []string{"b2"}},
+		{name: "as the worker", q: store.BookingQuery{UserID: workerID, Role: "worker"}, want: []string{"b1", "b2", "b3", "b4"}},
+		{name: "as someone else", q: store.BookingQuery{UserID: workerID}},
+		{name: "starts from", q: store.BookingQuery{StartsFrom: base.Add(2 * time.Hour)}, want: []string{"b3", "b4"}},
+		{name: "ends after", q: store.BookingQuery{EndsAfter: base.Add(2 * time.Hour)}, want: []string{"b3", "b4"}},
+		{name: "limit", q: store.BookingQuery{Limit: 2}, want: []string{"b1", "b2"}},
+		{name: "after a cursor", q: store.BookingQuery{After: &store.BookingCursor{ID: "b2", Value: base.Add(time.Hour)}, Limit: 1}, want: []string{"b3"}},
+	}
+	for _, tt := range tests {
+		list, err := st.Bookings().List(ctx, tt.q)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, b := range list {
+			got = append(got, b.ID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: List = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWithTxRollsBack(t *testing.T) {
+	ctx := context.Background()
+	st := New()
+	failed := errors.New("failed")
+
+	err := st.WithTx(ctx, func(tx store.Store) error {
+		if err := tx.Bookings().Create(ctx, booking("b1", 0, models.BookingStatusPending)); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("WithTx error = %v, want %v", err, failed)
+	}
+	if _, err := st.Bookings().Get(ctx, "b1"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("booking created in a rolled back transaction: Get error = %v", err)
+	}
+}
+
+// Writing through a booking's pointers must not reach the stored record or a snapshot of it
+func TestWithTxRollsBackPointees(t *testing.T) {
+	ctx := context.Background()
+	st := New()
+	failed := errors.New("failed")
+
+	notes := "bring the receipts"
+	created := booking("b1", 0, models.BookingStatusPending)
+	created.Notes = &notes
+	if err := st.Bookings().Create(ctx, created); err != nil {
+		t.Fatal(err)
+	}
+	notes = "changed after create"
+
+	err := st.WithTx(ctx, func(tx store.Store) error {
+		b, err := tx.Bookings().Get(ctx, "b1")
+		if err != nil {
+			return err
+		}
+		*b.Notes = "changed in the transaction"
+		if err := tx.Bookings().Update(ctx, b); err != nil {
+			return err
+		}
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("WithTx error = %v, want %v", err, failed)
+	}
+
+	b, err := st.Bookings().Get(ctx, "b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Notes == nil || *b.Notes != "bring the receipts" {
+		t.Errorf("notes = %v, want the ones the booking was created with", b.Notes)
+	}
+}
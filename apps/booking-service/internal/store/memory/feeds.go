@@ -0,0 +1,36 @@
+package memory
+
+import (
+	"context"
+
+	"booking-service/internal/store"
+)
+
+type calendarFeeds struct {
+	s *Store
+}
+
+func (r calendarFeeds) Put(ctx context.Context, feed *store.CalendarFeed) error {
+	defer r.s.lock()()
+
+	r.s.data.feeds[feed.UserID] = *feed
+	return nil
+}
+
+func (r calendarFeeds) Delete(ctx context.Context, userID string) error {
+	defer r.s.lock()()
+
+	delete(r.s.data.feeds, userID)
+	return nil
+}
+
+func (r calendarFeeds) GetByTokenHash(ctx context.Context, tokenHash string) (*store.CalendarFeed, error) {
+	defer r.s.lock()()
+
+	for _, feed := range r.s.data.feeds {
+		if feed.TokenHash == tokenHash {
+			return &feed, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"context"
+
+	"booking-service/internal/models"
+)
+
+type history struct {
+	s *Store
+}
+
+func (r history) Append(ctx context.Context, e *models.BookingEvent) error {
+	defer r.s.lock()()
+
+	r.s.data.history = append(r.s.data.history, *e)
+	return nil
+}
+
+func (r history) ListByBooking(ctx context.Context, bookingID string) ([]*models.BookingEvent, error) {
+	defer r.s.lock()()
+
+	list := make([]*models.BookingEvent, 0)
+	for _, e := range r.s.data.history {
+		if e.BookingID == bookingID {
+			list = append(list, &e)
+		}
+	}
+	return list, nil
+}
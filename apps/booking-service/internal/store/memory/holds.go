@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+type holds struct {
+	s *Store
+}
+
+func (r holds) Create(ctx context.Context, hold *models.SlotHold) error {
+	defer r.s.lock()()
+
+	r.s.data.holds[hold.ID] = *hold
+	return nil
+}
+
+func (r holds) Get(ctx context.Context, id string) (*models.SlotHold, error) {
+	defer r.s.lock()()
+
+	hold, ok := r.s.data.holds[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &hold, nil
+}
+
+func (r holds) Delete(ctx context.Context, id string) error {
+	defer r.s.lock()()
+
+	delete(r.s.data.holds, id)
+	return nil
+}
+
+func (r holds) filter(keep func(h *models.SlotHold) bool) []*models.SlotHold {
+	list := make([]*models.SlotHold, 0)
+	for _, hold := range r.s.data.holds {
+		if keep(&hold) {
+			list = append(list, &hold)
+		}
+	}
+	return list
+}
+
+func (r holds) ListActive(ctx context.Context, workerID string, from, to time.Time, now time.Time) ([]*models.SlotHold, error) {
+	defer r.s.lock()()
+
+	return r.filter(func(h *models.SlotHold) bool {
+		return h.WorkerID == workerID && h.ExpiresAt.After(now) && h.StartTime.Before(to) && h.EndTime.After(from)
+	}), nil
+}
+
+func (r holds) ListByClient(ctx context.Context, workerID, clientID string, now time.Time) ([]*models.SlotHold, error) {
+	defer r.s.lock()()
+
+	return r.filter(func(h *models.SlotHold) bool {
+		return h.WorkerID == workerID && h.ClientID == clientID && h.ExpiresAt.After(now)
+	}), nil
+}
+
+func (r holds) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	defer r.s.lock()()
+
+	removed := 0
+	for id, hold := range r.s.data.holds {
+		if !hold.ExpiresAt.After(now) {
+			delete(r.s.data.holds, id)
+			removed++
+		}
+	}
+	return removed, nil
+}
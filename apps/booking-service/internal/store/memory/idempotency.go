@@ -0,0 +1,72 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"booking-service/internal/store"
+)
+
+type idempotencyKey struct {
+	userID string
+	key    string
+}
+
+type idempotency struct {
+	s *Store
+}
+
+func (r idempotency) Reserve(ctx context.Context, rec *store.IdempotencyRecord) (*store.IdempotencyRecord, error) {
+	defer r.s.lock()()
+
+	k := idempotencyKey{rec.UserID, rec.Key}
+	if existing, ok := r.s.data.idempotency[k]; ok && existing.ExpiresAt.After(rec.CreatedAt) &&
+		(existing.StatusCode != 0 || existing.LockedUntil.After(rec.CreatedAt)) {
+		existing.Headers = maps.Clone(existing.Headers)
+		existing.Response = slices.Clone(existing.Response)
+		return &existing, nil
+	}
+
+	r.s.data.idempotency[k] = *rec
+	return nil, nil
+}
+
+func (r idempotency) Complete(ctx context.Context, userID, key, leaseToken string, statusCode int, headers map[string]string, response []byte) error {
+	defer r.s.lock()()
+
+	k := idempotencyKey{userID, key}
+	rec, ok := r.s.data.idempotency[k]
+	if !ok || rec.LeaseToken != leaseToken || rec.StatusCode != 0 {
+		return store.ErrNotFound
+	}
+	rec.StatusCode = statusCode
+	rec.Headers = maps.Clone(headers)
+	rec.Response = slices.Clone(response)
+	r.s.data.idempotency[k] = rec
+	return nil
+}
+
+func (r idempotency) Delete(ctx context.Context, userID, key, leaseToken string) error {
+	defer r.s.lock()()
+
+	k := idempotencyKey{userID, key}
+	if rec, ok := r.s.data.idempotency[k]; ok && rec.LeaseToken == leaseToken && rec.StatusCode == 0 {
+		delete(r.s.data.idempotency, k)
+	}
+	return nil
+}
+
+func (r idempotency) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	defer r.s.lock()()
+
+	removed := 0
+	for k, rec := range r.s.data.idempotency {
+		if !rec.ExpiresAt.After(now) {
+			delete(r.s.data.idempotency, k)
+			removed++
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+// Store keeps all data in process. Transactions take a store-wide lock, so they are serializable,
+// and restore a snapshot when they fail.
+type Store struct {
+	mu   *sync.Mutex
+	data *data
+	inTx bool
+}
+
+type blockedSlot struct {
+	workerID string
+	slot     models.BlockedSlot
+}
+
+type data struct {
+	bookings     map[string]models.Booking
+	series       map[string]models.BookingSeries
+	reschedules  map[string]models.RescheduleRequest
+	workers      map[string]store.WorkerSettings
+	blockedSlots map[string]blockedSlot
+	feeds        map[string]store.CalendarFeed
+	holds        map[string]models.SlotHold
+	history      []models.BookingEvent
+	reminders    map[string]models.BookingReminder
+	offsets      map[string][]int
+	idempotency  map[idempotencyKey]store.IdempotencyRecord
+	outbox       map[string]store.OutboxEvent
+}
+
+func New() *Store {
+	return &Store{
+		mu: &sync.Mutex{},
+		data: &data{
+			bookings:     map[string]models.Booking{},
+			series:       map[string]models.BookingSeries{},
+			reschedules:  map[string]models.RescheduleRequest{},
+			workers:      map[string]store.WorkerSettings{},
+			blockedSlots: map[string]blockedSlot{},
+			feeds:        map[string]store.CalendarFeed{},
+			holds:        map[string]models.SlotHold{},
+			history:      []models.BookingEvent{},
+			reminders:    map[string]models.BookingReminder{},
+			offsets:      map[string][]int{},
+			idempotency:  map[idempotencyKey]store.IdempotencyRecord{},
+			outbox:       map[string]store.OutboxEvent{},
+		},
+	}
+}
+
+// Records are stored by value and copied, along with what they point to, on the way in and out,
+// so a map clone is a snapshot
+func (d *data) clone() *data {
+	return &data{
+		bookings:     maps.Clone(d.bookings),
+		series:       maps.Clone(d.series),
+		reschedules:  maps.Clone(d.reschedules),
+		workers:      maps.Clone(d.workers),
+		blockedSlots: maps.Clone(d.blockedSlots),
+		feeds:        maps.Clone(d.feeds),
+		holds:        maps.Clone(d.holds),
+		history:      slices.Clone(d.history),
+		reminders:    maps.Clone(d.reminders),
+		offsets:      maps.Clone(d.offsets),
+		idempotency:  maps.Clone(d.idempotency),
+		outbox:       maps.Clone(d.outbox),
+	}
+}
+
+// lock guards a single call; inside a transaction the lock is already held
+func (s *Store) lock() func() {
+	if s.inTx {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *Store) Bookings() store.BookingStore           { return bookings{s} }
+func (s *Store) Series() store.SeriesStore              { return series{s} }
+func (s *Store) Reschedules() store.RescheduleStore     { return reschedules{s} }
+func (s *Store) Availability() store.AvailabilityStore  { return availability{s} }
+func (s *Store) BlockedSlots() store.BlockedSlotStore   { return blockedSlots{s} }
+func (s *Store) CalendarFeeds() store.CalendarFeedStore { return calendarFeeds{s} }
+func (s *Store) Holds() store.HoldStore                 { return holds{s} }
+func (s *Store) History() store.BookingHistoryStore     { return history{s} }
+func (s *Store) Reminders() store.ReminderStore         { return reminders{s} }
+func (s *Store) Idempotency() store.IdempotencyStore    { return idempotency{s} }
+func (s *Store) Outbox() store.OutboxStore              { return outbox{s} }
+
+func (s *Store) WithTx(ctx context.Context, fn func(tx store.Store) error) error {
+	if s.inTx {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.clone()
+	if err := fn(&Store{mu: s.mu, data: s.data, inTx: true}); err != nil {
+		*s.data = *snapshot
+		return err
+	}
+	return nil
+}
+
+// LockWorker is a no-op: transactions already exclude each other
+func (s *Store) LockWorker(ctx context.Context, workerID string) error {
+	return nil
+}
+
+func (s *Store) Close() {}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"booking-service/internal/store"
+)
+
+type outbox struct {
+	s *Store
+}
+
+func (r outbox) Add(ctx context.Context, e *store.OutboxEvent) error {
+	defer r.s.lock()()
+
+	r.s.data.outbox[e.ID] = *e
+	return nil
+}
+
+func (r outbox) Claim(ctx context.Context, now, lockedUntil time.Time, eventTypes []string, limit int) ([]*store.OutboxEvent, error) {
+	defer r.s.lock()()
+
+	list := make([]*store.OutboxEvent, 0)
+	for _, e := range r.s.data.outbox {
+		if eventTypes != nil && !slices.Contains(eventTypes, e.EventType) {
+			continue
+		}
+		if e.PublishedAt == nil && e.DeadLetteredAt == nil && !e.NextAttemptAt.After(now) && (e.LockedUntil == nil || !e.LockedUntil.After(now)) {
+			list = append(list, &e)
+		}
+	}
+	slices.SortFunc(list, func(a, b *store.OutboxEvent) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	for _, e := range list {
+		e.LockedUntil = &lockedUntil
+		r.s.data.outbox[e.ID] = *e
+	}
+	return list, nil
+}
+
+func (r outbox) MarkPublished(ctx context.Context, id string, at time.Time) error {
+	return r.update(id, func(e *store.OutboxEvent) {
+		e.PublishedAt = &at
+		e.Attempts++
+		e.LastError = nil
+	})
+}
+
+func (r outbox) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	return r.update(id, func(e *store.OutboxEvent) {
+		e.Attempts++
+		e.LastError = &lastError
+		e.NextAttemptAt = nextAttemptAt
+	})
+}
+
+func (r outbox) MarkDeadLettered(ctx context.Context, id string, lastError string, at time.Time) error {
+	return r.update(id, func(e *store.OutboxEvent) {
+		e.Attempts++
+		e.LastError = &lastError
+		e.DeadLetteredAt = &at
+	})
+}
+
+// update applies fn to the event and releases its lease
+func (r outbox) update(id string, fn func(e *store.OutboxEvent)) error {
+	defer r.s.lock()()
+
+	e, ok := r.s.data.outbox[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	fn(&e)
+	e.LockedUntil = nil
+	r.s.data.outbox[id] = e
+	return nil
+}
+
+func (r outbox) ListDeadLettered(ctx context.Context, limit int) ([]*store.OutboxEvent, error) {
+	defer r.s.lock()()
+
+	list := make([]*store.OutboxEvent, 0)
+	for _, e := range r.s.data.outbox {
+		if e.DeadLetteredAt != nil {
+			list = append(list, &e)
+		}
+	}
+	slices.SortFunc(list, func(a, b *store.OutboxEvent) int {
+		if c := b.DeadLetteredAt.Compare(*a.DeadLetteredAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (r outbox) Requeue(ctx context.Context, id string, now time.Time) error {
+	defer r.s.lock()()
+
+	e, ok := r.s.data.outbox[id]
+	if !ok || e.DeadLetteredAt == nil {
+		return store.ErrNotFound
+	}
+	e.DeadLetteredAt = nil
+	e.Attempts = 0
+	e.NextAttemptAt = now
+	r.s.data.outbox[id] = e
+	return nil
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+type reminders struct {
+	s *Store
+}
+
+func (r reminders) list(keep func(*models.BookingReminder) bool) []*models.BookingReminder {
+	list := make([]*models.BookingReminder, 0)
+	for _, rem := range r.s.data.reminders {
+		if keep(&rem) {
+			list = append(list, &rem)
+		}
+	}
+	slices.SortFunc(list, func(a, b *models.BookingReminder) int {
+		if c := a.DueAt.Compare(b.DueAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ID, b.ID)
+	})
+	return list
+}
+
+func (r reminders) ListByBooking(ctx context.Context, bookingID string) ([]*models.BookingReminder, error) {
+	defer r.s.lock()()
+
+	return r.list(func(rem *models.BookingReminder) bool {
+		return rem.BookingID == bookingID
+	}), nil
+}
+
+func (r reminders) ReplacePending(ctx context.Context, bookingID string, list []*models.BookingReminder) error {
+	defer r.s.lock()()
+
+	for id, rem := range r.s.data.reminders {
+		if rem.BookingID == bookingID && rem.SentAt == nil {
+			delete(r.s.data.reminders, id)
+		}
+	}
+	for _, rem := range list {
+		r.s.data.reminders[rem.ID] = *rem
+	}
+	return nil
+}
+
+func (r reminders) ListDue(ctx context.Context, now time.Time, limit int) ([]*models.BookingReminder, error) {
+	defer r.s.lock()()
+
+	list := r.list(func(rem *models.BookingReminder) bool {
+		return rem.SentAt == nil && !rem.DueAt.After(now)
+	})
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (r reminders) MarkSent(ctx context.Context, id string, at time.Time) error {
+	defer r.s.lock()()
+
+	rem, ok := r.s.data.reminders[id]
+	if !ok {
+		return store.ErrNotFound
+	}
+	rem.SentAt = &at
+	r.s.data.reminders[id] = rem
+	return nil
+}
+
+func (r reminders) Delete(ctx context.Context, id string) error {
+	defer r.s.lock()()
+
+	delete(r.s.data.reminders, id)
+	return nil
+}
+
+func (r reminders) GetOffsets(ctx context.Context, userID string) ([]int, error) {
+	defer r.s.lock()()
+
+	offsets, ok := r.s.data.offsets[userID]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return slices.Clone(offsets), nil
+}
+
+func (r reminders) SetOffsets(ctx context.Context, userID string, offsets []int) error {
+	defer r.s.lock()()
+
+	r.s.data.offsets[userID] = slices.Clone(offsets)
+	return nil
+}
+
+func (r reminders) DeleteOffsets(ctx context.Context, userID string) error {
+	defer r.s.lock()()
+
+	delete(r.s.data.offsets, userID)
+	return nil
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+type reschedules struct {
+	s *Store
+}
+
+func (r reschedules) Create(ctx context.Context, req *models.RescheduleRequest) error {
+	defer r.s.lock()()
+
+	r.s.data.reschedules[req.ID] = *req
+	return nil
+}
+
+func (r reschedules) Get(ctx context.Context, id string) (*models.RescheduleRequest, error) {
+	defer r.s.lock()()
+
+	req, ok := r.s.data.reschedules[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return &req, nil
+}
+
+func (r reschedules) Update(ctx context.Context, req *models.RescheduleRequest) error {
+	defer r.s.lock()()
+
+	current, ok := r.s.data.reschedules[req.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	current.Status = req.Status
+	current.RespondedBy = req.RespondedBy
+	current.RespondedAt = req.RespondedAt
+	r.s.data.reschedules[req.ID] = current
+	return nil
+}
+
+func (r reschedules) ListByBooking(ctx context.Context, bookingID string) ([]*models.RescheduleRequest, error) {
+	defer r.s.lock()()
+
+	list := make([]*models.RescheduleRequest, 0)
+	for _, req := range r.s.data.reschedules {
+		if req.BookingID == bookingID {
+			list = append(list, &req)
+		}
+	}
+	slices.SortFunc(list, func(a, b *models.RescheduleRequest) int {
+		return cmp.Compare(b.CreatedAt.UnixNano(), a.CreatedAt.UnixNano())
+	})
+	return list, nil
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+)
+
+type series struct {
+	s *Store
+}
+
+func (r series) Create(ctx context.Context, s *models.BookingSeries) error {
+	defer r.s.lock()()
+
+	stored := *s
+	stored.ExceptionDates = slices.Clone(s.ExceptionDates)
+	stored.Occurrences = nil
+	r.s.data.series[s.ID] = stored
+	return nil
+}
+
+func (r series) Get(ctx context.Context, id string) (*models.BookingSeries, error) {
+	defer r.s.lock()()
+
+	s, ok := r.s.data.series[id]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	s.ExceptionDates = slices.Clone(s.ExceptionDates)
+	return &s, nil
+}
+
+func (r series) Update(ctx context.Context, s *models.BookingSeries) error {
+	defer r.s.lock()()
+
+	current, ok := r.s.data.series[s.ID]
+	if !ok {
+		return store.ErrNotFound
+	}
+	current.Title = s.Title
+	current.Description = s.Description
+	current.RRule = s.RRule
+	current.ExceptionDates = slices.Clone(s.ExceptionDates)
+	current.Status = s.Status
+	current.Notes = s.Notes
+	current.UpdatedAt = s.UpdatedAt
+	r.s.data.series[s.ID] = current
+	return nil
+}
@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type availability struct {
+	q querier
+}
+
+func (r availability) Get(ctx context.Context, workerID string) (*store.WorkerSettings, error) {
+	settings := &store.WorkerSettings{}
+
+	var availabilityJSON, rulesJSON, policyJSON, rateCardJSON []byte
+	var timezone, currency *string
+	err := r.q.QueryRow(ctx, `
+		SELECT availability, timezone, currency, hourly_rate, scheduling_rules, cancellation_policy, rate_card
+		FROM worker_profiles WHERE user_id = $1
+	`, workerID).Scan(&availabilityJSON, &timezone, &currency, &settings.HourlyRate, &rulesJSON, &policyJSON, &rateCardJSON)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return settings, nil
+		}
+		return nil, err
+	}
+
+	if availabilityJSON != nil {
+		json.Unmarshal(availabilityJSON, &settings.Availability)
+	}
+	if timezone != nil {
+		settings.Timezone = *timezone
+	}
+	if currency != nil {
+		settings.Currency = *currency
+	}
+	settings.SchedulingRules = rulesJSON
+	if policyJSON != nil {
+		json.Unmarshal(policyJSON, &settings.CancellationPolicy)
+	}
+	if rateCardJSON != nil {
+		json.Unmarshal(rateCardJSON, &settings.RateCard)
+	}
+
+	return settings, nil
+}
+
+// The setters upsert because the worker_profiles row may not exist yet
+
+func (r availability) SetAvailability(ctx context.Context, workerID string, slots []models.AvailabilitySlot) error {
+	slotsJSON, _ := json.Marshal(slots)
+
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO worker_profiles (user_id, availability)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET availability = $2
+	`, workerID, slotsJSON)
+	return err
+}
+
+func (r availability) SetSchedulingRules(ctx context.Context, workerID string, rules *models.SchedulingRules) error {
+	rulesJSON, _ := json.Marshal(rules)
+
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO worker_profiles (user_id, scheduling_rules)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET scheduling_rules = $2
+	`, workerID, rulesJSON)
+	return err
+}
+
+func (r availability) SetCancellationPolicy(ctx context.Context, workerID string, policy *models.CancellationPolicy) error {
+	policyJSON, _ := json.Marshal(policy)
+
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO worker_profiles (user_id, cancellation_policy)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET cancellation_policy = $2
+	`, workerID, policyJSON)
+	return err
+}
+
+func (r availability) SetRateCard(ctx context.Context, workerID string, card *models.RateCard) error {
+	cardJSON, _ := json.Marshal(card)
+
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO worker_profiles (user_id, rate_card)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET rate_card = $2
+	`, workerID, cardJSON)
+	return err
+}
+
+type blockedSlots struct {
+	q querier
+}
+
+func (r blockedSlots) Create(ctx context.Context, workerID string, slot *models.BlockedSlot) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO blocked_slots (id, worker_id, start_time, end_time, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, slot.ID, workerID, slot.StartTime, slot.EndTime, slot.Reason, time.Now())
+	return err
+}
+
+func (r blockedSlots) Delete(ctx context.Context, workerID string, id string) error {
+	_, err := r.q.Exec(ctx, "DELETE FROM blocked_slots WHERE id = $1 AND worker_id = $2", id, workerID)
+	return err
+}
+
+func (r blockedSlots) ListOverlapping(ctx context.Context, workerID string, from, to time.Time) ([]*models.BlockedSlot, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, start_time, end_time, COALESCE(reason, '') FROM blocked_slots
+		WHERE worker_id = $1 AND start_time < $3 AND end_time > $2
+	`, workerID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*models.BlockedSlot, 0)
+	for rows.Next() {
+		slot := &models.BlockedSlot{}
+		if err := rows.Scan(&slot.ID, &slot.StartTime, &slot.EndTime, &slot.Reason); err != nil {
+			return nil, err
+		}
+		list = append(list, slot)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type bookings struct {
+	q querier
+}
+
+const bookingSelect = `
+		SELECT b.id, b.worker_id, b.client_id, b.project_id, b.series_id, b.series_exception, b.title, b.description,
+		       b.start_time, b.end_time, b.duration, b.hourly_rate, b.total_amount,
+		       b.currency, b.status, b.is_remote, b.meeting_url, b.notes, b.created_at, b.updated_at, b.version,
+		       b.price_breakdown, b.cancellation_policy, b.cancellation,
+		       w.id, w.first_name, w.last_name, w.avatar_url,
+		       c.id, c.first_name, c.last_name, c.avatar_url
+		FROM bookings b
+		LEFT JOIN users w ON b.worker_id = w.id
+		LEFT JOIN users c ON b.client_id = c.id`
+
+func scanBooking(row pgx.Row) (*models.Booking, error) {
+	b := &models.Booking{}
+	w := &models.UserInfo{}
+	c := &models.UserInfo{}
+	var priceJSON, policyJSON, cancellationJSON []byte
+	err := row.Scan(
+		&b.ID, &b.WorkerID, &b.ClientID, &b.ProjectID, &b.SeriesID, &b.SeriesException, &b.Title, &b.Description,
+		&b.StartTime, &b.EndTime, &b.Duration, &b.HourlyRate, &b.TotalAmount,
+		&b.Currency, &b.Status, &b.IsRemote, &b.MeetingURL, &b.Notes, &b.CreatedAt, &b.UpdatedAt, &b.Version,
+		&priceJSON, &policyJSON, &cancellationJSON,
+		&w.ID, &w.FirstName, &w.LastName, &w.AvatarUrl,
+		&c.ID, &c.FirstName, &c.LastName, &c.AvatarUrl,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if priceJSON != nil {
+		json.Unmarshal(priceJSON, &b.PriceBreakdown)
+	}
+	if policyJSON != nil {
+		json.Unmarshal(policyJSON, &b.CancellationPolicy)
+	}
+	if cancellationJSON != nil {
+		json.Unmarshal(cancellationJSON, &b.Cancellation)
+	}
+
+	if w.ID != "" {
+		b.Worker = w
+	}
+	if c.ID != "" {
+		b.Client = c
+	}
+
+	return b, nil
+}
+
+func (r bookings) list(ctx context.Context, where string, args ...any) ([]*models.Booking, error) {
+	rows, err := r.q.Query(ctx, bookingSelect+" WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*models.Booking, 0)
+	for rows.Next() {
+		b, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, b)
+	}
+
+	return list, rows.Err()
+}
+
+func (r bookings) Create(ctx context.Context, b *models.Booking) error {
+	var priceJSON, policyJSON []byte
+	if b.PriceBreakdown != nil {
+		priceJSON, _ = json.Marshal(b.PriceBreakdown)
+	}
+	if b.CancellationPolicy != nil {
+		policyJSON, _ = json.Marshal(b.CancellationPolicy)
+	}
+
+	b.Version = 1
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO bookings (id, worker_id, client_id, project_id, series_id, title, description, start_time, end_time, duration, hourly_rate, total_amount, currency, status, is_remote, meeting_url, notes, price_breakdown, cancellation_policy, created_at, updated_at, version)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+	`, b.ID, b.WorkerID, b.ClientID, b.ProjectID, b.SeriesID, b.Title, b.Description, b.StartTime, b.EndTime, b.Duration, b.HourlyRate, b.TotalAmount, b.Currency, b.Status, b.IsRemote, b.MeetingURL, b.Notes, priceJSON, policyJSON, b.CreatedAt, b.UpdatedAt, b.Version)
+	return overlap(err)
+}
+
+func (r bookings) Get(ctx context.Context, id string) (*models.Booking, error) {
+	b, err := scanBooking(r.q.QueryRow(ctx, bookingSelect+" WHERE b.id = $1", id))
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return b, nil
+}
+
+func (r bookings) Update(ctx context.Context, b *models.Booking) error {
+	return r.update(ctx, b, "")
+}
+
+func (r bookings) Transition(ctx context.Context, b *models.Booking, from string) error {
+	return r.update(ctx, b, from)
+}
+
+// update writes b over the stored row only while that row is still at b.Version and, unless from
+// is empty, in status from
+func (r bookings) update(ctx context.Context, b *models.Booking, from string) error {
+	var priceJSON, cancellationJSON []byte
+	if b.PriceBreakdown != nil {
+		priceJSON, _ = json.Marshal(b.PriceBreakdown)
+	}
+	if b.Cancellation != nil {
+		cancellationJSON, _ = json.Marshal(b.Cancellation)
+	}
+
+	tag, err := r.q.Exec(ctx, `
+		UPDATE bookings SET series_id = $1, series_exception = $2, title = $3, description = $4, start_time = $5, end_time = $6,
+		       duration = $7, hourly_rate = $8, total_amount = $9, currency = $10, price_breakdown = $11, status = $12, is_remote = $13,
+		       meeting_url = $14, notes = $15, cancellation = $16, updated_at = $17, version = version + 1
+		WHERE id = $18 AND version = $19 AND ($20::text = '' OR status = $20::text)
+	`, b.SeriesID, b.SeriesException, b.Title, b.Description, b.StartTime, b.EndTime, b.Duration, b.HourlyRate, b.TotalAmount, b.Currency, priceJSON, b.Status, b.IsRemote, b.MeetingURL, b.Notes, cancellationJSON, b.UpdatedAt, b.ID, b.Version, from)
+	if err != nil {
+		return overlap(err)
+	}
+	if tag.RowsAffected() == 0 {
+		return store.ErrConflict
+	}
+	b.Version++
+	return nil
+}
+
+func (r bookings) List(ctx context.Context, q store.BookingQuery) ([]*models.Booking, error) {
+	var conds []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	userCol, counterpartyCol := "b.client_id", "b.worker_id"
+	if q.Role == "worker" {
+		userCol, counterpartyCol = "b.worker_id", "b.client_id"
+	}
+	if q.UserID != "" {
+		conds = append(conds, userCol+" = "+arg(q.UserID))
+	}
+
+	if len(q.Statuses) > 0 {
+		conds = append(conds, "b.status = ANY("+arg(q.Statuses)+")")
+	}
+	if !q.StartsFrom.IsZero() {
+		conds = append(conds, "b.start_time >= "+arg(q.StartsFrom))
+	}
+	if !q.StartsBefore.IsZero() {
+		conds = append(conds, "b.start_time < "+arg(q.StartsBefore))
+	}
+	if !q.EndsAfter.IsZero() {
+		conds = append(conds, "b.end_time > "+arg(q.EndsAfter))
+	}
+	if !q.EndedBy.IsZero() {
+		conds = append(conds, "b.end_time <= "+arg(q.EndedBy))
+	}
+	if q.CounterpartyID != "" {
+		conds = append(conds, counterpartyCol+" = "+arg(q.CounterpartyID))
+	}
+	if q.WorkerID != "" {
+		conds = append(conds, "b.worker_id = "+arg(q.WorkerID))
+	}
+	if q.ClientID != "" {
+		conds = append(conds, "b.client_id = "+arg(q.ClientID))
+	}
+	if q.ProjectID != "" {
+		conds = append(conds, "b.project_id = "+arg(q.ProjectID))
+	}
+	if q.MissingMeeting {
+		conds = append(conds, "b.is_remote AND b.meeting_url IS NULL")
+	}
+
+	// Only whitelisted column names reach the query text
+	sortCol := "b.start_time"
+	if q.SortField == store.SortCreatedAt {
+		sortCol = "b.created_at"
+	}
+	cmp, dir := ">", "ASC"
+	if q.Descending {
+		cmp, dir = "<", "DESC"
+	}
+	if q.After != nil {
+		conds = append(conds, fmt.Sprintf("(%s, b.id) %s (%s, %s)", sortCol, cmp, arg(q.After.Value), arg(q.After.ID)))
+	}
+
+	where := "TRUE"
+	if len(conds) > 0 {
+		where = strings.Join(conds, " AND ")
+	}
+	where += fmt.Sprintf(" ORDER BY %s %s, b.id %s", sortCol, dir, dir)
+	if q.Limit > 0 {
+		where += " LIMIT " + arg(q.Limit)
+	}
+
+	return r.list(ctx, where, args...)
+}
+
+func (r bookings) ListBySeries(ctx context.Context, seriesID string) ([]*models.Booking, error) {
+	return r.list(ctx, "b.series_id = $1 ORDER BY b.start_time", seriesID)
+}
+
+func (r bookings) ListActive(ctx context.Context, workerID string, from, to time.Time) ([]*models.Booking, error) {
+	return r.list(ctx, "b.worker_id = $1 AND b.start_time < $3 AND b.end_time > $2 AND b.status NOT IN ('cancelled', 'declined', 'expired') ORDER BY b.start_time", workerID, from, to)
+}
+
+// dueColumns whitelists the columns ListDue may select on
+var dueColumns = map[string]string{
+	store.DueCreatedAt: "b.created_at",
+	store.DueStartTime: "b.start_time",
+	store.DueEndTime:   "b.end_time",
+}
+
+func (r bookings) ListDue(ctx context.Context, status string, field string, cutoff time.Time, limit int) ([]*models.Booking, error) {
+	col, ok := dueColumns[field]
+	if !ok {
+		return nil, fmt.Errorf("unknown due field %q", field)
+	}
+	return r.list(ctx, fmt.Sprintf("b.status = $1 AND %s <= $2 ORDER BY %s, b.id LIMIT $3 FOR UPDATE OF b SKIP LOCKED", col, col), status, cutoff, limit)
+}
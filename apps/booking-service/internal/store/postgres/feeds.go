@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+
+	"booking-service/internal/store"
+)
+
+type calendarFeeds struct {
+	q querier
+}
+
+func (r calendarFeeds) Put(ctx context.Context, feed *store.CalendarFeed) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO calendar_feeds (user_id, token_hash, role, created_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET token_hash = $2, role = $3, created_at = $4
+	`, feed.UserID, feed.TokenHash, feed.Role, feed.CreatedAt)
+	return err
+}
+
+func (r calendarFeeds) Delete(ctx context.Context, userID string) error {
+	_, err := r.q.Exec(ctx, "DELETE FROM calendar_feeds WHERE user_id = $1", userID)
+	return err
+}
+
+func (r calendarFeeds) GetByTokenHash(ctx context.Context, tokenHash string) (*store.CalendarFeed, error) {
+	feed := &store.CalendarFeed{}
+	err := r.q.QueryRow(ctx, "SELECT user_id, token_hash, role, created_at FROM calendar_feeds WHERE token_hash = $1", tokenHash).Scan(&feed.UserID, &feed.TokenHash, &feed.Role, &feed.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return feed, nil
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+
+	"booking-service/internal/models"
+)
+
+type history struct {
+	q querier
+}
+
+func (r history) Append(ctx context.Context, e *models.BookingEvent) error {
+	var changesJSON []byte
+	if len(e.Changes) > 0 {
+		changesJSON, _ = json.Marshal(e.Changes)
+	}
+
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO booking_events (id, booking_id, actor_id, actor_role, action, from_status, to_status, changes, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, e.ID, e.BookingID, e.ActorID, e.ActorRole, e.Action, e.FromStatus, e.ToStatus, changesJSON, e.Reason, e.CreatedAt)
+	return err
+}
+
+func (r history) ListByBooking(ctx context.Context, bookingID string) ([]*models.BookingEvent, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT id, booking_id, actor_id, actor_role, action, from_status, to_status, changes, reason, created_at
+		FROM booking_events WHERE booking_id = $1 ORDER BY created_at, seq
+	`, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*models.BookingEvent, 0)
+	for rows.Next() {
+		e := &models.BookingEvent{}
+		var changesJSON []byte
+		if err := rows.Scan(&e.ID, &e.BookingID, &e.ActorID, &e.ActorRole, &e.Action, &e.FromStatus, &e.ToStatus, &changesJSON, &e.Reason, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if changesJSON != nil {
+			json.Unmarshal(changesJSON, &e.Changes)
+		}
+		list = append(list, e)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"booking-service/internal/models"
+)
+
+type holds struct {
+	q querier
+}
+
+const holdSelect = "SELECT id, worker_id, client_id, start_time, end_time, expires_at, created_at FROM slot_holds"
+
+func (r holds) Create(ctx context.Context, hold *models.SlotHold) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO slot_holds (id, worker_id, client_id, start_time, end_time, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, hold.ID, hold.WorkerID, hold.ClientID, hold.StartTime, hold.EndTime, hold.ExpiresAt, hold.CreatedAt)
+	return err
+}
+
+func (r holds) Get(ctx context.Context, id string) (*models.SlotHold, error) {
+	hold := &models.SlotHold{}
+	err := r.q.QueryRow(ctx, holdSelect+" WHERE id = $1", id).Scan(&hold.ID, &hold.WorkerID, &hold.ClientID, &hold.StartTime, &hold.EndTime, &hold.ExpiresAt, &hold.CreatedAt)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return hold, nil
+}
+
+func (r holds) Delete(ctx context.Context, id string) error {
+	_, err := r.q.Exec(ctx, "DELETE FROM slot_holds WHERE id = $1", id)
+	return err
+}
+
+func (r holds) list(ctx context.Context, where string, args ...any) ([]*models.SlotHold, error) {
+	rows, err := r.q.Query(ctx, holdSelect+" WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*models.SlotHold, 0)
+	for rows.Next() {
+		hold := &models.SlotHold{}
+		if err := rows.Scan(&hold.ID, &hold.WorkerID, &hold.ClientID, &hold.StartTime, &hold.EndTime, &hold.ExpiresAt, &hold.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, hold)
+	}
+
+	return list, rows.Err()
+}
+
+func (r holds) ListActive(ctx context.Context, workerID string, from, to time.Time, now time.Time) ([]*models.SlotHold, error) {
+	return r.list(ctx, "worker_id = $1 AND start_time < $3 AND end_time > $2 AND expires_at > $4", workerID, from, to, now)
+}
+
+func (r holds) ListByClient(ctx context.Context, workerID, clientID string, now time.Time) ([]*models.SlotHold, error) {
+	return r.list(ctx, "worker_id = $1 AND client_id = $2 AND expires_at > $3", workerID, clientID, now)
+}
+
+func (r holds) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	tag, err := r.q.Exec(ctx, "DELETE FROM slot_holds WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
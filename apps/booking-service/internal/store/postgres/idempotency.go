@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"booking-service/internal/store"
+)
+
+type idempotency struct {
+	q querier
+}
+
+func (r idempotency) Reserve(ctx context.Context, rec *store.IdempotencyRecord) (*store.IdempotencyRecord, error) {
+	// The conditional upsert only overwrites a record that has expired or whose request died
+	// holding the lease, so of two concurrent requests with the same key exactly one gets the
+	// reservation. Rows from before leases existed have no locked_until and are taken over.
+	tag, err := r.q.Exec(ctx, `
+		INSERT INTO idempotency_keys (user_id, idempotency_key, fingerprint, lease_token, locked_until, status_code, response, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NULL, NULL, $6, $7)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE
+		SET fingerprint = EXCLUDED.fingerprint, lease_token = EXCLUDED.lease_token, locked_until = EXCLUDED.locked_until,
+		    status_code = NULL, response_headers = NULL, response = NULL,
+		    created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+		   OR (idempotency_keys.status_code IS NULL AND COALESCE(idempotency_keys.locked_until, idempotency_keys.created_at) <= EXCLUDED.created_at)
+	`, rec.UserID, rec.Key, rec.Fingerprint, rec.LeaseToken, rec.LockedUntil, rec.CreatedAt, rec.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	existing := &store.IdempotencyRecord{}
+	var statusCode *int
+	var leaseToken *string
+	var lockedUntil *time.Time
+	err = r.q.QueryRow(ctx, `
+		SELECT user_id, idempotency_key, fingerprint, lease_token, locked_until, status_code, response_headers, response, created_at, expires_at
+		FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2
+	`, rec.UserID, rec.Key).Scan(&existing.UserID, &existing.Key, &existing.Fingerprint, &leaseToken, &lockedUntil, &statusCode, &existing.Headers, &existing.Response, &existing.CreatedAt, &existing.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != nil {
+		existing.StatusCode = *statusCode
+	}
+	if leaseToken != nil {
+		existing.LeaseToken = *leaseToken
+	}
+	if lockedUntil != nil {
+		existing.LockedUntil = *lockedUntil
+	}
+	return existing, nil
+}
+
+func (r idempotency) Complete(ctx context.Context, userID, key, leaseToken string, statusCode int, headers map[string]string, response []byte) error {
+	tag, err := r.q.Exec(ctx, `
+		UPDATE idempotency_keys SET status_code = $4, response_headers = $5, response = $6
+		WHERE user_id = $1 AND idempotency_key = $2 AND lease_token = $3 AND status_code IS NULL
+	`, userID, key, leaseToken, statusCode, headers, response)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (r idempotency) Delete(ctx context.Context, userID, key, leaseToken string) error {
+	_, err := r.q.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2 AND lease_token = $3 AND status_code IS NULL
+	`, userID, key, leaseToken)
+	return err
+}
+
+func (r idempotency) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	tag, err := r.q.Exec(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"booking-service/internal/store"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const outboxColumns = `id, aggregate_type, aggregate_id, event_type, payload, attempts, last_error, next_attempt_at,
+	locked_until, published_at, dead_lettered_at, created_at`
+
+type outbox struct {
+	q querier
+}
+
+func (r outbox) Add(ctx context.Context, e *store.OutboxEvent) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO outbox_events (id, aggregate_type, aggregate_id, event_type, payload, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, e.ID, e.AggregateType, e.AggregateID, e.EventType, e.Payload, e.NextAttemptAt, e.CreatedAt)
+	return err
+}
+
+func (r outbox) Claim(ctx context.Context, now, lockedUntil time.Time, eventTypes []string, limit int) ([]*store.OutboxEvent, error) {
+	// SKIP LOCKED keeps concurrent claims from waiting on each other; the lease keeps them from
+	// taking the same events once this statement has committed
+	rows, err := r.q.Query(ctx, `
+		UPDATE outbox_events SET locked_until = $2
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE published_at IS NULL AND dead_lettered_at IS NULL AND next_attempt_at <= $1
+			  AND (locked_until IS NULL OR locked_until <= $1)
+			  AND ($4::text[] IS NULL OR event_type = ANY($4))
+			ORDER BY created_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+outboxColumns, now, lockedUntil, limit, eventTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := scanOutboxEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING does not keep the subquery's order
+	slices.SortFunc(list, func(a, b *store.OutboxEvent) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+	return list, nil
+}
+
+func (r outbox) MarkPublished(ctx context.Context, id string, at time.Time) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE outbox_events SET published_at = $1, attempts = attempts + 1, last_error = NULL, locked_until = NULL
+		WHERE id = $2
+	`, at, id)
+	return err
+}
+
+func (r outbox) MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE outbox_events SET attempts = attempts + 1, last_error = $1, next_attempt_at = $2, locked_until = NULL
+		WHERE id = $3
+	`, lastError, nextAttemptAt, id)
+	return err
+}
+
+func (r outbox) MarkDeadLettered(ctx context.Context, id string, lastError string, at time.Time) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE outbox_events SET attempts = attempts + 1, last_error = $1, dead_lettered_at = $2, locked_until = NULL
+		WHERE id = $3
+	`, lastError, at, id)
+	return err
+}
+
+func (r outbox) ListDeadLettered(ctx context.Context, limit int) ([]*store.OutboxEvent, error) {
+	rows, err := r.q.Query(ctx, `
+		SELECT `+outboxColumns+`
+		FROM outbox_events
+		WHERE dead_lettered_at IS NOT NULL
+		ORDER BY dead_lettered_at DESC, id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanOutboxEvents(rows)
+}
+
+func (r outbox) Requeue(ctx context.Context, id string, now time.Time) error {
+	tag, err := r.q.Exec(ctx, `
+		UPDATE outbox_events SET dead_lettered_at = NULL, attempts = 0, next_attempt_at = $1
+		WHERE id = $2 AND dead_lettered_at IS NOT NULL
+	`, now, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func scanOutboxEvents(rows pgx.Rows) ([]*store.OutboxEvent, error) {
+	defer rows.Close()
+
+	list := make([]*store.OutboxEvent, 0)
+	for rows.Next() {
+		e := &store.OutboxEvent{}
+		if err := rows.Scan(&e.ID, &e.AggregateType, &e.AggregateID, &e.EventType, &e.Payload, &e.Attempts, &e.LastError, &e.NextAttemptAt,
+			&e.LockedUntil, &e.PublishedAt, &e.DeadLetteredAt, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
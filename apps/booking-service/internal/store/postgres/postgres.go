@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"booking-service/internal/store"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// querier is satisfied by both *pgxpool.Pool and pgx.Tx
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+type Store struct {
+	pool *pgxpool.Pool
+	q    querier
+	tx   pgx.Tx
+}
+
+func New(ctx context.Context, databaseURL string) (*Store, error) {
+	pool, err := pgxpool.New(ctx, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{pool: pool, q: pool}, nil
+}
+
+func (s *Store) Bookings() store.BookingStore           { return bookings{s.q} }
+func (s *Store) Series() store.SeriesStore              { return series{s.q} }
+func (s *Store) Reschedules() store.RescheduleStore     { return reschedules{s.q} }
+func (s *Store) Availability() store.AvailabilityStore  { return availability{s.q} }
+func (s *Store) BlockedSlots() store.BlockedSlotStore   { return blockedSlots{s.q} }
+func (s *Store) CalendarFeeds() store.CalendarFeedStore { return calendarFeeds{s.q} }
+func (s *Store) Holds() store.HoldStore                 { return holds{s.q} }
+func (s *Store) History() store.BookingHistoryStore     { return history{s.q} }
+func (s *Store) Reminders() store.ReminderStore         { return reminders{s.q} }
+func (s *Store) Idempotency() store.IdempotencyStore    { return idempotency{s.q} }
+func (s *Store) Outbox() store.OutboxStore              { return outbox{s.q} }
+
+func (s *Store) WithTx(ctx context.Context, fn func(tx store.Store) error) error {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&Store{pool: s.pool, q: tx, tx: tx}); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func (s *Store) LockWorker(ctx context.Context, workerID string) error {
+	_, err := s.q.Exec(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", workerID)
+	return err
+}
+
+func (s *Store) Close() {
+	if s.tx == nil {
+		s.pool.Close()
+	}
+}
+
+// notFound maps a missing row to store.ErrNotFound. So does an ID that is not a valid UUID
+// (invalid_text_representation), which cannot name any row.
+func notFound(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.Is(err, pgx.ErrNoRows) || (errors.As(err, &pgErr) && pgErr.Code == "22P02") {
+		return store.ErrNotFound
+	}
+	return err
+}
+
+// overlap maps a violation of the bookings_no_overlap exclusion constraint to store.ErrOverlap
+func overlap(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23P01" {
+		return store.ErrOverlap
+	}
+	return err
+}
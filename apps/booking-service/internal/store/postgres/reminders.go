@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"booking-service/internal/models"
+)
+
+type reminders struct {
+	q querier
+}
+
+const reminderSelect = `SELECT id, booking_id, user_id, offset_minutes, due_at, sent_at, created_at FROM booking_reminders`
+
+func (r reminders) list(ctx context.Context, where string, args ...any) ([]*models.BookingReminder, error) {
+	rows, err := r.q.Query(ctx, reminderSelect+" WHERE "+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*models.BookingReminder, 0)
+	for rows.Next() {
+		rem := &models.BookingReminder{}
+		if err := rows.Scan(&rem.ID, &rem.BookingID, &rem.UserID, &rem.OffsetMinutes, &rem.DueAt, &rem.SentAt, &rem.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, rem)
+	}
+
+	return list, rows.Err()
+}
+
+func (r reminders) ListByBooking(ctx context.Context, bookingID string) ([]*models.BookingReminder, error) {
+	return r.list(ctx, "booking_id = $1 ORDER BY due_at, id", bookingID)
+}
+
+func (r reminders) ReplacePending(ctx context.Context, bookingID string, list []*models.BookingReminder) error {
+	if _, err := r.q.Exec(ctx, "DELETE FROM booking_reminders WHERE booking_id = $1 AND sent_at IS NULL", bookingID); err != nil {
+		return err
+	}
+
+	for _, rem := range list {
+		_, err := r.q.Exec(ctx, `
+			INSERT INTO booking_reminders (id, booking_id, user_id, offset_minutes, due_at, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, rem.ID, rem.BookingID, rem.UserID, rem.OffsetMinutes, rem.DueAt, rem.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r reminders) ListDue(ctx context.Context, now time.Time, limit int) ([]*models.BookingReminder, error) {
+	return r.list(ctx, "sent_at IS NULL AND due_at <= $1 ORDER BY due_at, id LIMIT $2 FOR UPDATE SKIP LOCKED", now, limit)
+}
+
+func (r reminders) MarkSent(ctx context.Context, id string, at time.Time) error {
+	_, err := r.q.Exec(ctx, "UPDATE booking_reminders SET sent_at = $1 WHERE id = $2", at, id)
+	return err
+}
+
+func (r reminders) Delete(ctx context.Context, id string) error {
+	_, err := r.q.Exec(ctx, "DELETE FROM booking_reminders WHERE id = $1", id)
+	return err
+}
+
+func (r reminders) GetOffsets(ctx context.Context, userID string) ([]int, error) {
+	var offsets []int
+	err := r.q.QueryRow(ctx, "SELECT offsets_minutes FROM reminder_preferences WHERE user_id = $1", userID).Scan(&offsets)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return offsets, nil
+}
+
+func (r reminders) SetOffsets(ctx context.Context, userID string, offsets []int) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO reminder_preferences (user_id, offsets_minutes, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET offsets_minutes = $2, updated_at = NOW()
+	`, userID, offsets)
+	return err
+}
+
+func (r reminders) DeleteOffsets(ctx context.Context, userID string) error {
+	_, err := r.q.Exec(ctx, "DELETE FROM reminder_preferences WHERE user_id = $1", userID)
+	return err
+}
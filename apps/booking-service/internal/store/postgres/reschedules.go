@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+
+	"booking-service/internal/models"
+)
+
+type reschedules struct {
+	q querier
+}
+
+const rescheduleSelect = `
+		SELECT id, booking_id, proposed_by, start_time, end_time, reason, status, responded_by, responded_at, created_at
+		FROM booking_reschedule_requests`
+
+func (r reschedules) Create(ctx context.Context, req *models.RescheduleRequest) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO booking_reschedule_requests (id, booking_id, proposed_by, start_time, end_time, reason, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, req.ID, req.BookingID, req.ProposedBy, req.StartTime, req.EndTime, req.Reason, req.Status, req.CreatedAt)
+	return err
+}
+
+func (r reschedules) Get(ctx context.Context, id string) (*models.RescheduleRequest, error) {
+	req := &models.RescheduleRequest{}
+	err := r.q.QueryRow(ctx, rescheduleSelect+" WHERE id = $1 FOR UPDATE", id).Scan(
+		&req.ID, &req.BookingID, &req.ProposedBy, &req.StartTime, &req.EndTime, &req.Reason, &req.Status, &req.RespondedBy, &req.RespondedAt, &req.CreatedAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return req, nil
+}
+
+func (r reschedules) Update(ctx context.Context, req *models.RescheduleRequest) error {
+	_, err := r.q.Exec(ctx, "UPDATE booking_reschedule_requests SET status = $1, responded_by = $2, responded_at = $3 WHERE id = $4", req.Status, req.RespondedBy, req.RespondedAt, req.ID)
+	return err
+}
+
+func (r reschedules) ListByBooking(ctx context.Context, bookingID string) ([]*models.RescheduleRequest, error) {
+	rows, err := r.q.Query(ctx, rescheduleSelect+" WHERE booking_id = $1 ORDER BY created_at DESC", bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]*models.RescheduleRequest, 0)
+	for rows.Next() {
+		req := &models.RescheduleRequest{}
+		if err := rows.Scan(&req.ID, &req.BookingID, &req.ProposedBy, &req.StartTime, &req.EndTime, &req.Reason, &req.Status, &req.RespondedBy, &req.RespondedAt, &req.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, req)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+
+	"booking-service/internal/models"
+)
+
+type series struct {
+	q querier
+}
+
+func (r series) Create(ctx context.Context, s *models.BookingSeries) error {
+	_, err := r.q.Exec(ctx, `
+		INSERT INTO booking_series (id, worker_id, client_id, project_id, parent_series_id, title, description, rrule, start_time, duration, hourly_rate, currency, timezone, exception_dates, status, notes, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`, s.ID, s.WorkerID, s.ClientID, s.ProjectID, s.ParentSeriesID, s.Title, s.Description, s.RRule, s.StartTime, s.Duration, s.HourlyRate, s.Currency, s.Timezone, s.ExceptionDates, s.Status, s.Notes, s.CreatedAt, s.UpdatedAt)
+	return err
+}
+
+func (r series) Get(ctx context.Context, id string) (*models.BookingSeries, error) {
+	s := &models.BookingSeries{}
+	err := r.q.QueryRow(ctx, `
+		SELECT id, worker_id, client_id, project_id, parent_series_id, title, description, rrule, start_time,
+		       duration, hourly_rate, currency, timezone, exception_dates, status, notes, created_at, updated_at
+		FROM booking_series
+		WHERE id = $1
+	`, id).Scan(
+		&s.ID, &s.WorkerID, &s.ClientID, &s.ProjectID, &s.ParentSeriesID, &s.Title, &s.Description, &s.RRule, &s.StartTime,
+		&s.Duration, &s.HourlyRate, &s.Currency, &s.Timezone, &s.ExceptionDates, &s.Status, &s.Notes, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return s, nil
+}
+
+func (r series) Update(ctx context.Context, s *models.BookingSeries) error {
+	_, err := r.q.Exec(ctx, `
+		UPDATE booking_series SET title = $1, description = $2, rrule = $3, exception_dates = $4, status = $5, notes = $6, updated_at = $7
+		WHERE id = $8
+	`, s.Title, s.Description, s.RRule, s.ExceptionDates, s.Status, s.Notes, s.UpdatedAt, s.ID)
+	return err
+}
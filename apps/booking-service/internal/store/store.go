@@ -0,0 +1,266 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"booking-service/internal/models"
+	"booking-service/internal/money"
+)
+
+var (
+	ErrNotFound = errors.New("record not found")
+	// ErrOverlap means a write would leave two active bookings overlapping on a worker's calendar
+	ErrOverlap = errors.New("booking overlaps another active booking")
+	// ErrConflict means the row changed since it was read, so a conditional write was not applied
+	ErrConflict = errors.New("record was modified concurrently")
+)
+
+// Store is the persistence boundary of the service. The postgres package backs it with the
+// shared database; the memory package keeps everything in process for local development and tests.
+type Store interface {
+	Bookings() BookingStore
+	Series() SeriesStore
+	Reschedules() RescheduleStore
+	Availability() AvailabilityStore
+	BlockedSlots() BlockedSlotStore
+	CalendarFeeds() CalendarFeedStore
+	Holds() HoldStore
+	History() BookingHistoryStore
+	Reminders() ReminderStore
+	Idempotency() IdempotencyStore
+	Outbox() OutboxStore
+
+	// WithTx runs fn against a Store bound to a single transaction, committing when fn returns nil
+	// and rolling back otherwise. Calling WithTx on a transactional Store reuses its transaction.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+	// LockWorker serializes calendar writes for a worker until the surrounding transaction ends
+	LockWorker(ctx context.Context, workerID string) error
+	Close()
+}
+
+type BookingStore interface {
+	Create(ctx context.Context, b *models.Booking) error
+	Get(ctx context.Context, id string) (*models.Booking, error)
+	// Update writes every mutable field of the booking if the stored row is still at b.Version,
+	// failing with ErrConflict otherwise. On success b.Version is bumped.
+	Update(ctx context.Context, b *models.Booking) error
+	// Transition is Update for a status change; the stored status must also still be from
+	Transition(ctx context.Context, b *models.Booking, from string) error
+	List(ctx context.Context, q BookingQuery) ([]*models.Booking, error)
+	ListBySeries(ctx context.Context, seriesID string) ([]*models.Booking, error)
+	// ListActive returns the worker's bookings overlapping [from, to) that still hold their slot,
+	// i.e. everything except cancelled, declined and expired bookings
+	ListActive(ctx context.Context, workerID string, from, to time.Time) ([]*models.Booking, error)
+	// ListDue returns up to limit bookings in status whose field (one of the Due* columns) is at
+	// or before cutoff, earliest first. Inside a transaction the rows stay claimed until it ends,
+	// and rows claimed by other transactions are skipped.
+	ListDue(ctx context.Context, status string, field string, cutoff time.Time, limit int) ([]*models.Booking, error)
+}
+
+const (
+	SortStartTime = "start_time"
+	SortCreatedAt = "created_at"
+)
+
+// Booking timestamps ListDue can select on
+const (
+	DueCreatedAt = "created_at"
+	DueStartTime = "start_time"
+	DueEndTime   = "end_time"
+)
+
+// BookingCursor is the sort value and id of the last booking on the previous page
+type BookingCursor struct {
+	Value time.Time
+	ID    string
+}
+
+// BookingQuery selects the bookings where the user is the worker (role "worker") or the client;
+// an empty UserID selects across all users, for staff. Zero values leave a filter unset. Results
+// are ordered by SortField, then id, so that keyset pagination with After is stable.
+type BookingQuery struct {
+	UserID         string
+	Role           string
+	Statuses       []string
+	StartsFrom     time.Time
+	StartsBefore   time.Time
+	EndsAfter      time.Time
+	EndedBy        time.Time
+	CounterpartyID string
+	WorkerID       string
+	ClientID       string
+	ProjectID      string
+	// MissingMeeting selects remote bookings that have no meeting link
+	MissingMeeting bool
+	SortField      string
+	Descending     bool
+	After          *BookingCursor
+	// Limit of zero returns every match
+	Limit int
+}
+
+type SeriesStore interface {
+	Create(ctx context.Context, series *models.BookingSeries) error
+	Get(ctx context.Context, id string) (*models.BookingSeries, error)
+	Update(ctx context.Context, series *models.BookingSeries) error
+}
+
+// BookingHistoryStore is the append-only audit trail of bookings
+type BookingHistoryStore interface {
+	Append(ctx context.Context, e *models.BookingEvent) error
+	// ListByBooking returns the booking's events, oldest first
+	ListByBooking(ctx context.Context, bookingID string) ([]*models.BookingEvent, error)
+}
+
+type ReminderStore interface {
+	// ListByBooking returns the booking's reminders, soonest first
+	ListByBooking(ctx context.Context, bookingID string) ([]*models.BookingReminder, error)
+	// ReplacePending deletes the booking's unsent reminders and creates reminders in their place
+	ReplacePending(ctx context.Context, bookingID string, reminders []*models.BookingReminder) error
+	// ListDue returns up to limit unsent reminders due at now, soonest first. Inside a transaction
+	// the rows stay claimed until it ends, and rows claimed by other transactions are skipped.
+	ListDue(ctx context.Context, now time.Time, limit int) ([]*models.BookingReminder, error)
+	MarkSent(ctx context.Context, id string, at time.Time) error
+	Delete(ctx context.Context, id string) error
+
+	// GetOffsets returns the user's reminder offsets in minutes, or ErrNotFound when they use the
+	// defaults
+	GetOffsets(ctx context.Context, userID string) ([]int, error)
+	SetOffsets(ctx context.Context, userID string, offsets []int) error
+	DeleteOffsets(ctx context.Context, userID string) error
+}
+
+type RescheduleStore interface {
+	Create(ctx context.Context, r *models.RescheduleRequest) error
+	// Get locks the request for the rest of the transaction
+	Get(ctx context.Context, id string) (*models.RescheduleRequest, error)
+	Update(ctx context.Context, r *models.RescheduleRequest) error
+	// ListByBooking returns the booking's requests, newest first
+	ListByBooking(ctx context.Context, bookingID string) ([]*models.RescheduleRequest, error)
+}
+
+// WorkerSettings holds the calendar settings kept on a worker's profile. A worker without a
+// profile gets the zero value.
+type WorkerSettings struct {
+	Availability []models.AvailabilitySlot
+	Timezone     string
+	// Currency is the ISO 4217 code the worker prices in; empty when they haven't picked one
+	Currency string
+	// HourlyRate is the rate on the worker's profile, nil when they haven't set one
+	HourlyRate *money.Amount
+	RateCard   *models.RateCard
+	// SchedulingRules is stored as a partial document; fields it leaves out keep their defaults
+	SchedulingRules    json.RawMessage
+	CancellationPolicy *models.CancellationPolicy
+}
+
+type AvailabilityStore interface {
+	Get(ctx context.Context, workerID string) (*WorkerSettings, error)
+	SetAvailability(ctx context.Context, workerID string, slots []models.AvailabilitySlot) error
+	SetSchedulingRules(ctx context.Context, workerID string, rules *models.SchedulingRules) error
+	SetCancellationPolicy(ctx context.Context, workerID string, policy *models.CancellationPolicy) error
+	SetRateCard(ctx context.Context, workerID string, card *models.RateCard) error
+}
+
+type BlockedSlotStore interface {
+	Create(ctx context.Context, workerID string, slot *models.BlockedSlot) error
+	Delete(ctx context.Context, workerID string, id string) error
+	ListOverlapping(ctx context.Context, workerID string, from, to time.Time) ([]*models.BlockedSlot, error)
+}
+
+type CalendarFeed struct {
+	UserID    string
+	TokenHash string
+	Role      string
+	CreatedAt time.Time
+}
+
+type CalendarFeedStore interface {
+	// Put stores the user's feed, replacing any previous one
+	Put(ctx context.Context, feed *CalendarFeed) error
+	Delete(ctx context.Context, userID string) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*CalendarFeed, error)
+}
+
+type HoldStore interface {
+	Create(ctx context.Context, hold *models.SlotHold) error
+	Get(ctx context.Context, id string) (*models.SlotHold, error)
+	Delete(ctx context.Context, id string) error
+	// ListActive returns the worker's holds overlapping [from, to) that haven't expired at now
+	ListActive(ctx context.Context, workerID string, from, to time.Time, now time.Time) ([]*models.SlotHold, error)
+	// ListByClient returns the client's unexpired holds on the worker's calendar
+	ListByClient(ctx context.Context, workerID, clientID string, now time.Time) ([]*models.SlotHold, error)
+	// DeleteExpired removes holds that expired by now and returns how many it removed
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+// IdempotencyRecord remembers the response to a request sent with an Idempotency-Key. Keys are
+// scoped to the user who sent them. StatusCode is zero while the first request is in flight;
+// Headers holds the response headers replayed along with the body.
+//
+// An in-flight record is leased to the request holding LeaseToken until LockedUntil. If that
+// request dies without completing or releasing the key, a retry takes the record over once the
+// lease has run out.
+type IdempotencyRecord struct {
+	UserID      string
+	Key         string
+	Fingerprint string
+	LeaseToken  string
+	LockedUntil time.Time
+	StatusCode  int
+	Headers     map[string]string
+	Response    []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+type IdempotencyStore interface {
+	// Reserve stores rec unless the user already has a live record for the key, in which case it
+	// returns that record instead. Expired records and in-flight records whose lease ran out are
+	// replaced.
+	Reserve(ctx context.Context, rec *IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete stores the response and Delete releases the key, as long as the reservation with
+	// leaseToken still holds it
+	Complete(ctx context.Context, userID, key, leaseToken string, statusCode int, headers map[string]string, response []byte) error
+	Delete(ctx context.Context, userID, key, leaseToken string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+type OutboxEvent struct {
+	ID            string
+	AggregateType string
+	AggregateID   string
+	EventType     string
+	Payload       []byte
+	Attempts      int
+	LastError     *string
+	NextAttemptAt time.Time
+	// LockedUntil is the end of the lease a relay holds on the event while it publishes it
+	LockedUntil *time.Time
+	PublishedAt *time.Time
+	// DeadLetteredAt is when the relay gave up on the event; it is not retried unless requeued
+	DeadLetteredAt *time.Time
+	CreatedAt      time.Time
+}
+
+type OutboxStore interface {
+	Add(ctx context.Context, e *OutboxEvent) error
+	// Claim leases up to limit unpublished events due at now until lockedUntil and returns them,
+	// oldest first. Events leased by another relay are skipped until their lease runs out, so
+	// the claim commits at once and the events are published outside any transaction. A nil
+	// eventTypes claims events of any type.
+	Claim(ctx context.Context, now, lockedUntil time.Time, eventTypes []string, limit int) ([]*OutboxEvent, error)
+	// MarkPublished, MarkFailed and MarkDeadLettered record the outcome of a publish attempt and
+	// release the lease
+	MarkPublished(ctx context.Context, id string, at time.Time) error
+	MarkFailed(ctx context.Context, id string, lastError string, nextAttemptAt time.Time) error
+	MarkDeadLettered(ctx context.Context, id string, lastError string, at time.Time) error
+	// ListDeadLettered returns up to limit events the relay gave up on, most recent first
+	ListDeadLettered(ctx context.Context, limit int) ([]*OutboxEvent, error)
+	// Requeue schedules a dead-lettered event to be published again at now with a fresh set of
+	// attempts. It returns ErrNotFound unless the event is dead-lettered.
+	Requeue(ctx context.Context, id string, now time.Time) error
+}
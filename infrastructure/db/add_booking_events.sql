@@ -0,0 +1,18 @@
+-- Audit trail of bookings: one row per create, edit, reschedule and status transition.
+-- seq orders events written within the same instant. Cancel, decline and no-show reasons live
+-- here instead of being appended to bookings.notes.
+CREATE TABLE IF NOT EXISTS booking_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    seq BIGSERIAL,
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    actor_role VARCHAR(20) NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    changes JSONB,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_events_booking_id ON booking_events(booking_id, created_at, seq);
@@ -0,0 +1,16 @@
+-- Statuses set by the lifecycle scheduler: pending bookings nobody answered expire, and sessions
+-- past their end time await completion
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('pending', 'confirmed', 'in_progress', 'awaiting_completion', 'completed', 'cancelled', 'declined', 'no_show', 'expired'));
+
+-- Expired bookings no longer occupy the worker's calendar
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (worker_id WITH =, tstzrange(start_time, end_time, '[)') WITH &&)
+    WHERE (status NOT IN ('cancelled', 'declined', 'expired'));
+
+-- The scheduler looks up due bookings by status and time
+CREATE INDEX IF NOT EXISTS idx_bookings_status_start ON bookings(status, start_time);
+CREATE INDEX IF NOT EXISTS idx_bookings_status_end ON bookings(status, end_time);
+CREATE INDEX IF NOT EXISTS idx_bookings_pending_created ON bookings(created_at) WHERE status = 'pending';
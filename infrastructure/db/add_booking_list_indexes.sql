@@ -0,0 +1,5 @@
+-- Keyset pagination indexes for GET /api/bookings, one per (participant, sort field)
+CREATE INDEX IF NOT EXISTS idx_bookings_worker_start ON bookings(worker_id, start_time, id);
+CREATE INDEX IF NOT EXISTS idx_bookings_client_start ON bookings(client_id, start_time, id);
+CREATE INDEX IF NOT EXISTS idx_bookings_worker_created ON bookings(worker_id, created_at, id);
+CREATE INDEX IF NOT EXISTS idx_bookings_client_created ON bookings(client_id, created_at, id);
@@ -0,0 +1,5 @@
+-- Amounts follow their currency's ISO 4217 minor unit, which is up to four decimal places
+-- booking_series is created by add_booking_series.sql, which may run after this file
+ALTER TABLE IF EXISTS booking_series ALTER COLUMN hourly_rate TYPE DECIMAL(14, 4);
+ALTER TABLE bookings ALTER COLUMN hourly_rate TYPE DECIMAL(14, 4);
+ALTER TABLE bookings ALTER COLUMN total_amount TYPE DECIMAL(14, 4);
@@ -0,0 +1,35 @@
+-- Prevent overlapping bookings for the same worker at the database level
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_time_range_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_time_range_check CHECK (end_time > start_time);
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+
+-- Bookings made before the constraint may already overlap, and the constraint cannot be added
+-- while they do. The booking made first keeps its slot; any later one that overlaps it is
+-- cancelled and reported, so the parties can be told and rebook.
+DO $$
+DECLARE
+    b RECORD;
+BEGIN
+    FOR b IN SELECT id, worker_id, start_time, end_time, created_at FROM bookings WHERE status <> 'cancelled' ORDER BY created_at, id LOOP
+        IF EXISTS (
+            SELECT 1 FROM bookings o
+            WHERE o.worker_id = b.worker_id
+              AND o.id <> b.id
+              AND o.status <> 'cancelled'
+              AND (o.created_at < b.created_at OR (o.created_at = b.created_at AND o.id < b.id))
+              AND tstzrange(o.start_time, o.end_time, '[)') && tstzrange(b.start_time, b.end_time, '[)')
+        ) THEN
+            UPDATE bookings SET status = 'cancelled', updated_at = NOW() WHERE id = b.id;
+            RAISE NOTICE 'cancelled booking % of worker %: it overlaps an earlier booking', b.id, b.worker_id;
+        END IF;
+    END LOOP;
+END $$;
+
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (worker_id WITH =, tstzrange(start_time, end_time, '[)') WITH &&)
+    WHERE (status <> 'cancelled');
+
+CREATE INDEX IF NOT EXISTS idx_blocked_slots_worker_time ON blocked_slots(worker_id, start_time, end_time);
@@ -0,0 +1,21 @@
+-- Reminders sent to each party ahead of a booking. Unsent rows are rewritten whenever the booking
+-- is moved or changes status; sent rows are kept so the same reminder is never sent twice.
+CREATE TABLE IF NOT EXISTS booking_reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    offset_minutes INTEGER NOT NULL CHECK (offset_minutes > 0),
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Minutes before the start a user wants to be reminded at; users without a row get the defaults
+CREATE TABLE IF NOT EXISTS reminder_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    offsets_minutes INTEGER[] NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_reminders_due ON booking_reminders(due_at) WHERE sent_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_booking_reminders_booking_id ON booking_reminders(booking_id, due_at);
@@ -0,0 +1,3 @@
+-- Remote bookings are given a conferencing room when confirmed; its join link is stored in
+-- meeting_url and replaced or cleared when the booking is rescheduled or cancelled
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS is_remote BOOLEAN NOT NULL DEFAULT FALSE;
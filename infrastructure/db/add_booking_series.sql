@@ -0,0 +1,31 @@
+-- Recurring booking series; each occurrence is a booking linked to its series
+CREATE TABLE IF NOT EXISTS booking_series (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    worker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
+    parent_series_id UUID REFERENCES booking_series(id) ON DELETE SET NULL,
+    title VARCHAR(200) NOT NULL,
+    description TEXT,
+    rrule TEXT NOT NULL,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration INTEGER NOT NULL,
+    hourly_rate DECIMAL(14, 4) NOT NULL,
+    currency VARCHAR(3) DEFAULT 'USD',
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+    exception_dates TEXT[] DEFAULT '{}',
+    status VARCHAR(20) DEFAULT 'active' CHECK (status IN ('active', 'cancelled')),
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS series_id UUID REFERENCES booking_series(id) ON DELETE SET NULL;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS series_exception BOOLEAN DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_bookings_series_id ON bookings(series_id, start_time) WHERE series_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_booking_series_worker_id ON booking_series(worker_id);
+CREATE INDEX IF NOT EXISTS idx_booking_series_client_id ON booking_series(client_id);
+
+DROP TRIGGER IF EXISTS update_booking_series_updated_at ON booking_series;
+CREATE TRIGGER update_booking_series_updated_at BEFORE UPDATE ON booking_series FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
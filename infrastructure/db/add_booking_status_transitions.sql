@@ -0,0 +1,10 @@
+-- Add declined and no_show booking statuses
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_status_check;
+ALTER TABLE bookings ADD CONSTRAINT bookings_status_check
+    CHECK (status IN ('pending', 'confirmed', 'in_progress', 'completed', 'cancelled', 'declined', 'no_show'));
+
+-- Declined bookings no longer occupy the worker's calendar
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+ALTER TABLE bookings ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (worker_id WITH =, tstzrange(start_time, end_time, '[)') WITH &&)
+    WHERE (status NOT IN ('cancelled', 'declined'));
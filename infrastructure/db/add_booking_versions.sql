@@ -0,0 +1,3 @@
+-- Optimistic concurrency control: every write to a booking bumps its version, and writes are
+-- conditional on the version they read. The version doubles as the booking's ETag.
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
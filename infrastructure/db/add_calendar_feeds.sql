@@ -0,0 +1,7 @@
+-- Per-user iCalendar feed tokens; only a SHA-256 hash of the token is stored
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('worker', 'client')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
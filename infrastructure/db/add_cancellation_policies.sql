@@ -0,0 +1,8 @@
+-- Cancellation policies: set per worker, snapshotted onto each booking at creation.
+-- NULL on the worker means the default (flexible) policy.
+ALTER TABLE worker_profiles ADD COLUMN IF NOT EXISTS cancellation_policy JSONB;
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS cancellation_policy JSONB;
+
+-- Refund and penalty amounts computed when the booking was cancelled
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS cancellation JSONB;
@@ -0,0 +1,4 @@
+-- In-flight idempotency keys are leased to the request that reserved them, so a retry can take
+-- over a key whose request crashed before storing a response
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS lease_token UUID;
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,3 @@
+-- Headers of the stored response, such as Content-Type and the booking's ETag, sent again when
+-- an idempotent request is replayed
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS response_headers JSONB;
@@ -0,0 +1,10 @@
+-- The relay leases the outbox events it publishes instead of holding row locks while it talks to
+-- the broker, and dead-letters events that keep failing so they can be inspected and requeued
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS locked_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS dead_lettered_at TIMESTAMP WITH TIME ZONE;
+
+-- Events that already ran out of attempts were silently dropped until now
+UPDATE outbox_events SET dead_lettered_at = NOW()
+WHERE published_at IS NULL AND dead_lettered_at IS NULL AND attempts >= 10;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_dead_lettered ON outbox_events(dead_lettered_at) WHERE dead_lettered_at IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Short-lived reservations of a worker's time while a client checks out.
+-- Rows past expires_at no longer block the slot and are swept up by the booking service.
+CREATE TABLE IF NOT EXISTS slot_holds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    worker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT slot_holds_time_range_check CHECK (end_time > start_time)
+);
+
+CREATE INDEX IF NOT EXISTS idx_slot_holds_worker_time ON slot_holds(worker_id, start_time, end_time);
+CREATE INDEX IF NOT EXISTS idx_slot_holds_expires_at ON slot_holds(expires_at);
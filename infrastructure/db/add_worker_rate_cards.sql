@@ -0,0 +1,6 @@
+-- Rate cards: surcharges and rush fees on top of the worker's hourly rate.
+-- NULL means the profile's hourly_rate with no surcharges.
+ALTER TABLE worker_profiles ADD COLUMN IF NOT EXISTS rate_card JSONB;
+
+-- Price breakdown the booking was quoted with
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS price_breakdown JSONB;
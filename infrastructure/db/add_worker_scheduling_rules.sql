@@ -0,0 +1,2 @@
+-- Per-worker scheduling rules (buffers, notice, horizon, slot step, duration limits)
+ALTER TABLE worker_profiles ADD COLUMN IF NOT EXISTS scheduling_rules JSONB DEFAULT '{}';
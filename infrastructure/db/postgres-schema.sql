@@ -3,6 +3,7 @@
 
 -- Enable UUID extension
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+CREATE EXTENSION IF NOT EXISTS btree_gist;
 
 -- Users table
 CREATE TABLE IF NOT EXISTS users (
@@ -32,6 +33,9 @@ CREATE TABLE IF NOT EXISTS worker_profiles (
     country VARCHAR(100),
     timezone VARCHAR(50),
     availability JSONB DEFAULT '[]',
+    scheduling_rules JSONB DEFAULT '{}',
+    cancellation_policy JSONB,
+    rate_card JSONB,
     portfolio JSONB DEFAULT '[]',
     resume_url TEXT,
     linkedin_url TEXT,
@@ -133,25 +137,72 @@ CREATE TABLE IF NOT EXISTS agreements (
     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- Recurring booking series
+CREATE TABLE IF NOT EXISTS booking_series (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    worker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
+    parent_series_id UUID REFERENCES booking_series(id) ON DELETE SET NULL,
+    title VARCHAR(200) NOT NULL,
+    description TEXT,
+    rrule TEXT NOT NULL,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    duration INTEGER NOT NULL,
+    hourly_rate DECIMAL(14, 4) NOT NULL,
+    currency VARCHAR(3) DEFAULT 'USD',
+    timezone VARCHAR(50) NOT NULL DEFAULT 'UTC',
+    exception_dates TEXT[] DEFAULT '{}',
+    status VARCHAR(20) DEFAULT 'active' CHECK (status IN ('active', 'cancelled')),
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Bookings
 CREATE TABLE IF NOT EXISTS bookings (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
     worker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     client_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     project_id UUID REFERENCES projects(id) ON DELETE SET NULL,
+    series_id UUID REFERENCES booking_series(id) ON DELETE SET NULL,
+    series_exception BOOLEAN DEFAULT false,
     title VARCHAR(200) NOT NULL,
     description TEXT,
     start_time TIMESTAMP WITH TIME ZONE NOT NULL,
     end_time TIMESTAMP WITH TIME ZONE NOT NULL,
     duration INTEGER NOT NULL,
-    hourly_rate DECIMAL(10, 2) NOT NULL,
-    total_amount DECIMAL(10, 2) NOT NULL,
+    hourly_rate DECIMAL(14, 4) NOT NULL,
+    total_amount DECIMAL(14, 4) NOT NULL,
     currency VARCHAR(3) DEFAULT 'USD',
-    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'in_progress', 'completed', 'cancelled')),
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'confirmed', 'in_progress', 'awaiting_completion', 'completed', 'cancelled', 'declined', 'no_show', 'expired')),
+    is_remote BOOLEAN NOT NULL DEFAULT FALSE,
     meeting_url TEXT,
     notes TEXT,
+    price_breakdown JSONB,
+    cancellation_policy JSONB,
+    cancellation JSONB,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT bookings_time_range_check CHECK (end_time > start_time),
+    CONSTRAINT bookings_no_overlap EXCLUDE USING gist (worker_id WITH =, tstzrange(start_time, end_time, '[)') WITH &&)
+        WHERE (status NOT IN ('cancelled', 'declined', 'expired'))
+);
+
+-- Reschedule proposals
+CREATE TABLE IF NOT EXISTS booking_reschedule_requests (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    proposed_by UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'rejected', 'withdrawn', 'superseded')),
+    responded_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    responded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (end_time > start_time)
 );
 
 -- Blocked slots (for workers to block time)
@@ -164,6 +215,90 @@ CREATE TABLE IF NOT EXISTS blocked_slots (
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 );
 
+-- iCalendar feed tokens (hashed) for subscribing to a user's bookings
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('worker', 'client')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Short-lived slot reservations made during checkout
+CREATE TABLE IF NOT EXISTS slot_holds (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    worker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    start_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    end_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT slot_holds_time_range_check CHECK (end_time > start_time)
+);
+
+-- Audit trail of every change made to a booking
+CREATE TABLE IF NOT EXISTS booking_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    seq BIGSERIAL,
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    actor_role VARCHAR(20) NOT NULL,
+    action VARCHAR(30) NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    changes JSONB,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Reminders sent to each party ahead of a booking
+CREATE TABLE IF NOT EXISTS booking_reminders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    offset_minutes INTEGER NOT NULL CHECK (offset_minutes > 0),
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Per-user reminder offsets; users without a row get the defaults
+CREATE TABLE IF NOT EXISTS reminder_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    offsets_minutes INTEGER[] NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Cached responses for retried booking requests, keyed by the client's Idempotency-Key
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    lease_token UUID,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    status_code INTEGER,
+    response_headers JSONB,
+    response BYTEA,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+-- Outbox of booking domain events awaiting publication to RabbitMQ
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP WITH TIME ZONE,
+    published_at TIMESTAMP WITH TIME ZONE,
+    dead_lettered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
 -- Payments
 CREATE TABLE IF NOT EXISTS payments (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -219,6 +354,27 @@ CREATE INDEX idx_bids_worker_id ON bids(worker_id);
 CREATE INDEX idx_bookings_worker_id ON bookings(worker_id);
 CREATE INDEX idx_bookings_client_id ON bookings(client_id);
 CREATE INDEX idx_bookings_start_time ON bookings(start_time);
+CREATE INDEX idx_bookings_worker_start ON bookings(worker_id, start_time, id);
+CREATE INDEX idx_bookings_client_start ON bookings(client_id, start_time, id);
+CREATE INDEX idx_bookings_worker_created ON bookings(worker_id, created_at, id);
+CREATE INDEX idx_bookings_client_created ON bookings(client_id, created_at, id);
+CREATE INDEX idx_bookings_series_id ON bookings(series_id, start_time) WHERE series_id IS NOT NULL;
+CREATE INDEX idx_bookings_status_start ON bookings(status, start_time);
+CREATE INDEX idx_bookings_status_end ON bookings(status, end_time);
+CREATE INDEX idx_bookings_pending_created ON bookings(created_at) WHERE status = 'pending';
+CREATE INDEX idx_booking_series_worker_id ON booking_series(worker_id);
+CREATE INDEX idx_booking_series_client_id ON booking_series(client_id);
+CREATE INDEX idx_booking_reschedule_requests_booking_id ON booking_reschedule_requests(booking_id);
+CREATE INDEX idx_blocked_slots_worker_time ON blocked_slots(worker_id, start_time, end_time);
+CREATE INDEX idx_slot_holds_worker_time ON slot_holds(worker_id, start_time, end_time);
+CREATE INDEX idx_slot_holds_expires_at ON slot_holds(expires_at);
+CREATE INDEX idx_booking_events_booking_id ON booking_events(booking_id, created_at, seq);
+CREATE INDEX idx_booking_reminders_due ON booking_reminders(due_at) WHERE sent_at IS NULL;
+CREATE INDEX idx_booking_reminders_booking_id ON booking_reminders(booking_id, due_at);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+CREATE INDEX idx_outbox_events_unpublished ON outbox_events(next_attempt_at) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_events_aggregate ON outbox_events(aggregate_type, aggregate_id);
+CREATE INDEX idx_outbox_events_dead_lettered ON outbox_events(dead_lettered_at) WHERE dead_lettered_at IS NOT NULL;
 CREATE INDEX idx_payments_payer_id ON payments(payer_id);
 CREATE INDEX idx_payments_payee_id ON payments(payee_id);
 CREATE INDEX idx_reviews_reviewee_id ON reviews(reviewee_id);
@@ -242,4 +398,5 @@ CREATE TRIGGER update_worker_profiles_updated_at BEFORE UPDATE ON worker_profile
 CREATE TRIGGER update_client_profiles_updated_at BEFORE UPDATE ON client_profiles FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 CREATE TRIGGER update_projects_updated_at BEFORE UPDATE ON projects FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 CREATE TRIGGER update_bookings_updated_at BEFORE UPDATE ON bookings FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+CREATE TRIGGER update_booking_series_updated_at BEFORE UPDATE ON booking_series FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 CREATE TRIGGER update_agreements_updated_at BEFORE UPDATE ON agreements FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,7 @@
+-- Meeting links are bearer secrets, so the booking history only records that one changed.
+-- Redact the links recorded before that.
+UPDATE booking_events
+SET changes = jsonb_set(changes, '{meetingUrl}', jsonb_build_object(
+        'from', CASE WHEN changes->'meetingUrl'->'from' = 'null'::jsonb THEN 'null'::jsonb ELSE '"[redacted]"'::jsonb END,
+        'to', CASE WHEN changes->'meetingUrl'->'to' = 'null'::jsonb THEN 'null'::jsonb ELSE '"[redacted]"'::jsonb END))
+WHERE changes ? 'meetingUrl';